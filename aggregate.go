@@ -0,0 +1,412 @@
+package customorm
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// Aggregate functions
+const (
+	AggregateCount         = "COUNT"
+	AggregateCountDistinct = "COUNT DISTINCT"
+	AggregateSum           = "SUM"
+	AggregateAvg           = "AVG"
+	AggregateMin           = "MIN"
+	AggregateMax           = "MAX"
+)
+
+// AggregateFunction defines aggregate of the field returned under Alias
+type AggregateFunction struct {
+	Function string
+	// Field is struct field name, empty for COUNT(*)
+	Field string
+	Alias string
+}
+
+// HavingCondition compares aggregate with Alias to the value
+type HavingCondition struct {
+	Alias   string
+	Operand string
+	Value   interface{}
+}
+
+// Aggregation struct holding aggregates, grouping and conditions on groups.
+// Order fields are aliases of aggregates or grouped struct field names.
+type Aggregation struct {
+	Functions []AggregateFunction
+	GroupBy   []string
+	Having    []HavingCondition
+	Order     Order
+	Error     error
+}
+
+func (a *Aggregation) add(function, field, alias string) *Aggregation {
+	if a.Error != nil {
+		return a
+	}
+	if alias == "" {
+		alias = strings.ToLower(strings.Replace(function, " ", "_", -1))
+		if field != "" {
+			alias += "_" + ToSnakeCase(field)
+		}
+	}
+	a.Functions = append(a.Functions, AggregateFunction{Function: function, Field: field, Alias: alias})
+	return a
+}
+
+// Count adds COUNT(*), empty alias defaults to "count"
+func (a *Aggregation) Count(alias string) *Aggregation {
+	return a.add(AggregateCount, "", alias)
+}
+
+// CountDistinct adds COUNT(DISTINCT field), empty alias defaults to "count_distinct_<field>"
+func (a *Aggregation) CountDistinct(field, alias string) *Aggregation {
+	return a.add(AggregateCountDistinct, field, alias)
+}
+
+// Sum adds SUM(field), empty alias defaults to "sum_<field>"
+func (a *Aggregation) Sum(field, alias string) *Aggregation {
+	return a.add(AggregateSum, field, alias)
+}
+
+// Avg adds AVG(field), empty alias defaults to "avg_<field>"
+func (a *Aggregation) Avg(field, alias string) *Aggregation {
+	return a.add(AggregateAvg, field, alias)
+}
+
+// Min adds MIN(field), empty alias defaults to "min_<field>"
+func (a *Aggregation) Min(field, alias string) *Aggregation {
+	return a.add(AggregateMin, field, alias)
+}
+
+// Max adds MAX(field), empty alias defaults to "max_<field>"
+func (a *Aggregation) Max(field, alias string) *Aggregation {
+	return a.add(AggregateMax, field, alias)
+}
+
+// Group adds struct field names to GROUP BY
+func (a *Aggregation) Group(fields ...string) *Aggregation {
+	if a.Error != nil {
+		return a
+	}
+	a.GroupBy = append(a.GroupBy, fields...)
+	return a
+}
+
+// HavingValue adds condition comparing aggregate with the alias to the value
+func (a *Aggregation) HavingValue(alias, operand string, value interface{}) *Aggregation {
+	if a.Error != nil {
+		return a
+	}
+	switch operand {
+	case OperandEqual, OperandMore, OperandLess, OperandNotEqual:
+	default:
+		a.Error = errors.New("invalid operand")
+		return a
+	}
+	a.Having = append(a.Having, HavingCondition{Alias: alias, Operand: operand, Value: value})
+	return a
+}
+
+// aggregateQuery holds generated aggregate statement
+type aggregateQuery struct {
+	*selectQuery
+	// result keys of selected columns
+	keys []string
+	// aggregate columns converted from numeric text
+	aggregated []bool
+	groupBy    []string
+	having     []string
+}
+
+// Aggregate returns rows of aggregates over the table rows matched by filter, one row per group,
+// keyed by grouped field names and aggregate aliases
+func (c *CORM) Aggregate(s interface{}, filter Filters, aggregation Aggregation) ([]map[string]interface{}, error) {
+	oc, op := c.startTableOperation("Aggregate", s)
+	res, err := oc.aggregate(s, filter, aggregation, reflect.Value{})
+	op.finish(err)
+	return res, err
+}
+
+// AggregateInto fills dest pointer to slice of structs with rows of aggregates, struct fields are matched
+// by field name, its snake case or the first customsql tag value to grouped field names and aggregate aliases
+func (c *CORM) AggregateInto(s interface{}, filter Filters, aggregation Aggregation, dest interface{}) error {
+	oc, op := c.startTableOperation("AggregateInto", s)
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Slice || v.Elem().Type().Elem().Kind() != reflect.Struct {
+		err := errors.New("dest must be a pointer to slice of structs")
+		op.finish(err)
+		return err
+	}
+	_, err := oc.aggregate(s, filter, aggregation, v.Elem())
+	op.finish(err)
+	return err
+}
+
+func (c *CORM) aggregate(s interface{}, filter Filters, aggregation Aggregation, dest reflect.Value) ([]map[string]interface{}, error) {
+	if aggregation.Error != nil {
+		return nil, aggregation.Error
+	}
+	if len(aggregation.Functions) == 0 {
+		return nil, errors.New("no aggregate functions")
+	}
+	table, err := c.GetTable(s)
+	if err != nil {
+		return nil, err
+	}
+	q, err := c.newAggregateQuery(table, filter, aggregation)
+	if err != nil {
+		return nil, err
+	}
+	capLimit := c.limitRows(q.selectQuery, filter.Limit)
+
+	var targets [][]int
+	if dest.IsValid() {
+		targets, err = resultFields(dest.Type().Elem(), q.keys)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	results, st, err := c.query(q.sql(), q.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+	var rowsCount int64
+	defer func() { st.finish(rowsCount, err) }()
+	var res []map[string]interface{}
+	for results.Next() {
+		if capLimit > 0 && rowsCount == int64(capLimit) {
+			c.markCapReached()
+			err = ErrResultTruncated
+			return nil, err
+		}
+		var ptrs = make([]interface{}, len(q.keys))
+		if dest.IsValid() {
+			row := reflect.New(dest.Type().Elem()).Elem()
+			for i, index := range targets {
+				ptrs[i] = row.FieldByIndex(index).Addr().Interface()
+			}
+			err = results.Scan(ptrs...)
+			if err != nil {
+				return nil, err
+			}
+			dest.Set(reflect.Append(dest, row))
+			rowsCount++
+			continue
+		}
+		var values = make([]interface{}, len(q.keys))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		err = results.Scan(ptrs...)
+		if err != nil {
+			return nil, err
+		}
+		row := make(map[string]interface{}, len(q.keys))
+		for i, key := range q.keys {
+			row[key] = aggregateValue(values[i], q.aggregated[i])
+		}
+		res = append(res, row)
+		rowsCount++
+	}
+	if err = results.Err(); err != nil {
+		return nil, err
+	}
+	if capLimit > 0 && rowsCount >= int64(capLimit) {
+		c.markCapReached()
+	}
+	return res, nil
+}
+
+// newAggregateQuery generates aggregate statement of the table filtered by values
+func (c *CORM) newAggregateQuery(table Table, filter Filters, aggregation Aggregation) (*aggregateQuery, error) {
+	// select options of the filter would be dropped from the aggregate statement
+	switch {
+	case len(filter.Order.Fields) > 0:
+		return nil, errors.New("filter order is not used by aggregates, use aggregation order")
+	case filter.Distinct || len(filter.DistinctOn) > 0:
+		return nil, errors.New("filter distinct is not used by aggregates")
+	case len(filter.Select) > 0:
+		return nil, errors.New("filter select is not used by aggregates, use aggregation group")
+	}
+	sq, err := c.newSelectQuery(table, filter, true)
+	if err != nil {
+		return nil, err
+	}
+	sq.names = nil
+	sq.fnames = nil
+	q := &aggregateQuery{selectQuery: sq}
+
+	var expressions = map[string]string{}
+	for _, field := range aggregation.GroupBy {
+		name, ok := q.fieldColumn(field)
+		if !ok {
+			return nil, fmt.Errorf("unknown group field %s of table %s", field, table.Name)
+		}
+		q.names = append(q.names, name)
+		q.keys = append(q.keys, field)
+		q.aggregated = append(q.aggregated, false)
+		q.groupBy = append(q.groupBy, name)
+		expressions[field] = name
+	}
+	for _, f := range aggregation.Functions {
+		expr, err := q.aggregateExpression(f)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := expressions[f.Alias]; ok {
+			return nil, fmt.Errorf("duplicate aggregate alias %s", f.Alias)
+		}
+		q.names = append(q.names, expr+" AS "+pq.QuoteIdentifier(f.Alias))
+		q.keys = append(q.keys, f.Alias)
+		q.aggregated = append(q.aggregated, true)
+		expressions[f.Alias] = expr
+	}
+	for _, h := range aggregation.Having {
+		switch h.Operand {
+		case OperandEqual, OperandMore, OperandLess, OperandNotEqual:
+		default:
+			return nil, errors.New("invalid operand")
+		}
+		expr, ok := expressions[h.Alias]
+		if !ok || !isAggregateAlias(aggregation, h.Alias) {
+			return nil, fmt.Errorf("unknown aggregate alias %s", h.Alias)
+		}
+		q.having = append(q.having, expr+" "+h.Operand+" "+q.arg(h.Value))
+	}
+
+	q.order = ""
+	if len(aggregation.Order.Fields) > 0 {
+		var fields []string
+		for _, field := range aggregation.Order.Fields {
+			if _, ok := expressions[field]; !ok {
+				return nil, fmt.Errorf("unknown order field %s", field)
+			}
+			if isAggregateAlias(aggregation, field) {
+				field = pq.QuoteIdentifier(field)
+			} else {
+				field = expressions[field]
+			}
+			fields = append(fields, field)
+		}
+		desc := "ASC"
+		if aggregation.Order.Desc {
+			desc = "DESC"
+		}
+		q.order = fmt.Sprintf("ORDER BY %s %s", strings.Join(fields, ", "), desc)
+	}
+	return q, nil
+}
+
+// sql returns aggregate statement
+func (q *aggregateQuery) sql() string {
+	sqlReq := fmt.Sprintf(`SELECT %s FROM %s%s`, strings.Join(q.names, ", "), q.from(), q.where())
+	if len(q.groupBy) > 0 {
+		sqlReq += " GROUP BY " + strings.Join(q.groupBy, ", ")
+	}
+	if len(q.having) > 0 {
+		sqlReq += " HAVING " + strings.Join(q.having, " AND ")
+	}
+	if q.order != "" {
+		sqlReq += " " + q.order
+	}
+	if q.limit > 0 {
+		sqlReq += fmt.Sprintf(" LIMIT %d", q.limit)
+	}
+	if q.offset > 0 {
+		sqlReq += fmt.Sprintf(" OFFSET %d", q.offset)
+	}
+	return sqlReq + ";"
+}
+
+// aggregateExpression returns SQL expression of the aggregate function
+func (q *aggregateQuery) aggregateExpression(f AggregateFunction) (string, error) {
+	if f.Alias == "" {
+		return "", errors.New("empty aggregate alias")
+	}
+	if f.Function == AggregateCount && f.Field == "" {
+		return "COUNT(*)", nil
+	}
+	name, ok := q.fieldColumn(f.Field)
+	if !ok {
+		return "", fmt.Errorf("unknown aggregate field %s of table %s", f.Field, q.table.Name)
+	}
+	switch f.Function {
+	case AggregateCount, AggregateSum, AggregateAvg, AggregateMin, AggregateMax:
+		return fmt.Sprintf("%s(%s)", f.Function, name), nil
+	case AggregateCountDistinct:
+		return fmt.Sprintf("COUNT(DISTINCT %s)", name), nil
+	}
+	return "", fmt.Errorf("unknown aggregate function %s", f.Function)
+}
+
+// isAggregateAlias reports whether name is alias of the aggregate function
+func isAggregateAlias(aggregation Aggregation, name string) bool {
+	for _, f := range aggregation.Functions {
+		if f.Alias == name {
+			return true
+		}
+	}
+	return false
+}
+
+// columnOfField returns column name of the struct field name or of the column name itself
+func columnOfField(table Table, field string) (string, bool) {
+	for _, v := range table.Columns {
+		if v.FieldName == field || v.Name == field {
+			return v.Name, true
+		}
+	}
+	for _, v := range table.FKeys {
+		if v.FieldName == field || v.ColumnName == field {
+			return v.ColumnName, true
+		}
+	}
+	return "", false
+}
+
+// aggregateValue converts numeric aggregates returned as text to int64 or float64
+func aggregateValue(value interface{}, aggregated bool) interface{} {
+	b, ok := value.([]byte)
+	if !ok {
+		return value
+	}
+	if !aggregated {
+		return string(b)
+	}
+	if i, err := strconv.ParseInt(string(b), 10, 64); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(string(b), 64); err == nil {
+		return f
+	}
+	return string(b)
+}
+
+// resultFields returns indexes of the struct fields receiving result columns
+func resultFields(typ reflect.Type, keys []string) ([][]int, error) {
+	var targets [][]int
+	for _, key := range keys {
+		var index []int
+		for i := 0; i < typ.NumField(); i++ {
+			f := typ.Field(i)
+			tag := strings.Split(f.Tag.Get("customsql"), ";")[0]
+			if f.Name == key || ToSnakeCase(f.Name) == key || (tag != "" && tag == key) {
+				index = f.Index
+				break
+			}
+		}
+		if index == nil {
+			return nil, fmt.Errorf("no field of %s for result column %s", typ, key)
+		}
+		targets = append(targets, index)
+	}
+	return targets, nil
+}
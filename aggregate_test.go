@@ -0,0 +1,58 @@
+package customorm
+
+import (
+	"testing"
+)
+
+// aggregateSQL returns aggregate statement of the struct generated by newAggregateQuery
+func aggregateSQL(c *CORM, s interface{}, filter Filters, aggregation Aggregation) (string, []interface{}, error) {
+	table, err := c.GetTable(s)
+	if err != nil {
+		return "", nil, err
+	}
+	q, err := c.newAggregateQuery(table, filter, aggregation)
+	if err != nil {
+		return "", nil, err
+	}
+	return q.sql(), q.args, nil
+}
+
+func TestAggregateSQL(t *testing.T) {
+	c := Init(nil)
+	user := &testDomainUser{Name: "bob", Enabled: true, Parent: &testDomain{Name: "example"}}
+	filter := Filters{Fields: map[string]FilterFields{"Enabled": {Flag: true}, "Parent.Name": {Flag: true}}}
+	filter.InnerJoin("Parent")
+	grouped := Aggregation{}
+	grouped.Group("Parent").Count("").Sum("Position", "total").HavingValue("count", OperandMore, 1).HavingValue("total", OperandLess, 100)
+	grouped.Order = Order{Fields: []string{"total", "Parent"}, Desc: true}
+	counted := Aggregation{}
+	counted.Group("TenantId").CountDistinct("Number", "").HavingValue("count_distinct_number", OperandMore, 2)
+	checkSnapshots(t, []sqlSnapshot{
+		{
+			name:  "group by having",
+			run:   func() (string, []interface{}, error) { return aggregateSQL(c, user, filter, grouped) },
+			query: `SELECT "t"."parent_id", COUNT(*) AS "count", SUM("t"."position") AS "total" FROM "domain_users" AS "t" INNER JOIN "domains" AS "parent" ON "parent"."id" = "t"."parent_id" WHERE "t"."enabled" = $1 AND "parent"."name" = $2 AND "t"."deleted_at" IS NULL GROUP BY "t"."parent_id" HAVING COUNT(*) > $3 AND SUM("t"."position") < $4 ORDER BY "total", "t"."parent_id" DESC;`,
+			args:  []interface{}{true, "example", 1, 100},
+		},
+		{
+			name: "tenant having",
+			run: func() (string, []interface{}, error) {
+				return aggregateSQL(c.ForTenant(7), &testOrder{}, Filters{}, counted)
+			},
+			query: `SELECT "tenant_id", COUNT(DISTINCT "number") AS "count_distinct_number" FROM "billing"."order" WHERE "tenant_id" = $1 GROUP BY "tenant_id" HAVING COUNT(DISTINCT "number") > $2;`,
+			args:  []interface{}{int64(7), 2},
+		},
+	})
+
+	refused := []Filters{
+		{Order: Order{Fields: []string{"Name"}}},
+		{Distinct: true},
+		{DistinctOn: []string{"Name"}},
+		{Select: []string{"Name"}},
+	}
+	for _, f := range refused {
+		if _, _, err := aggregateSQL(c, user, f, grouped); err == nil {
+			t.Errorf("filter %+v is accepted", f)
+		}
+	}
+}
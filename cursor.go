@@ -0,0 +1,239 @@
+package customorm
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync/atomic"
+)
+
+// defaultCursorBatchSize is number of rows fetched at once by IterateCursor when batch size is not set
+const defaultCursorBatchSize = 1000
+
+// cursorCounter makes names of server-side cursors unique
+var cursorCounter uint64
+
+// Cursor streams rows of the table one by one without loading all of them into memory
+type Cursor struct {
+	c       *CORM
+	op      *operation
+	st      *statement
+	rows    *sql.Rows
+	scanner *rowScanner
+	row     reflect.Value
+	count   int64
+	err     error
+	closed  bool
+}
+
+// Cursor returns cursor over rows of the table matched by filter, rows are not limited except filter Limit.
+// Cursor must be closed after reading.
+func (c *CORM) Cursor(s interface{}, filter Filters) (*Cursor, error) {
+	oc, op := c.startTableOperation("Cursor", s)
+	cursor, err := oc.cursor(s, filter)
+	if err != nil {
+		op.finish(err)
+		return nil, err
+	}
+	cursor.op = op
+	return cursor, nil
+}
+
+func (c *CORM) cursor(s interface{}, filter Filters) (*Cursor, error) {
+	table, err := c.GetTable(s)
+	if err != nil {
+		return nil, err
+	}
+	q, err := c.newSelectQuery(table, filter, false)
+	if err != nil {
+		return nil, err
+	}
+	results, st, err := c.query(q.selectSql()+";", q.args...)
+	if err != nil {
+		return nil, err
+	}
+	return &Cursor{c: c, st: st, rows: results, scanner: newRowScanner(table, q.fnames)}, nil
+}
+
+// Next reads next row, returns false when there are no more rows or reading failed
+func (cur *Cursor) Next() bool {
+	if cur.closed || cur.err != nil {
+		return false
+	}
+	if !cur.rows.Next() {
+		cur.err = cur.rows.Err()
+		return false
+	}
+	row, err := cur.scanner.scan(cur.rows)
+	if err != nil {
+		cur.err = err
+		return false
+	}
+	cur.count++
+	err = cur.c.runHook(hookAfterFind, row.Addr().Interface())
+	if err != nil {
+		cur.err = err
+		return false
+	}
+	cur.row = row
+	return true
+}
+
+// Scan copies current row into dest, dest must be a pointer to the table struct
+func (cur *Cursor) Scan(dest interface{}) error {
+	if !cur.row.IsValid() {
+		return errors.New("no current row")
+	}
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Type() != cur.row.Type() {
+		return fmt.Errorf("dest must be a pointer to %s", cur.row.Type())
+	}
+	v.Elem().Set(cur.row)
+	return nil
+}
+
+// Row returns current row as the table struct value
+func (cur *Cursor) Row() interface{} {
+	if !cur.row.IsValid() {
+		return nil
+	}
+	return cur.row.Interface()
+}
+
+// Err returns error occurred while reading rows
+func (cur *Cursor) Err() error {
+	return cur.err
+}
+
+// Close releases rows of the cursor, it is safe to call it more than once
+func (cur *Cursor) Close() error {
+	if cur.closed {
+		return cur.err
+	}
+	cur.closed = true
+	err := cur.rows.Close()
+	if cur.err == nil {
+		cur.err = err
+	}
+	cur.st.finish(cur.count, cur.err)
+	if cur.op != nil {
+		cur.op.finish(cur.err)
+	}
+	return cur.err
+}
+
+// Iterate calls fn for every row of the table matched by filter, stops on the first fn error and returns it.
+// Rows stay open while fn runs, so fn can not run statements in the same transaction.
+func (c *CORM) Iterate(s interface{}, filter Filters, fn func(row interface{}) error) error {
+	oc, op := c.startTableOperation("Iterate", s)
+	err := oc.iterate(s, filter, fn)
+	op.finish(err)
+	return err
+}
+
+func (c *CORM) iterate(s interface{}, filter Filters, fn func(row interface{}) error) error {
+	cursor, err := c.cursor(s, filter)
+	if err != nil {
+		return err
+	}
+	for cursor.Next() {
+		err = fn(cursor.Row())
+		if err != nil {
+			cursor.Close()
+			return err
+		}
+	}
+	return cursor.Close()
+}
+
+// IterateCursor calls fn for every row of the table matched by filter reading rows by server-side cursor
+// in batches of batchSize. It runs in the active transaction or in own one committed after the last row.
+func (c *CORM) IterateCursor(s interface{}, filter Filters, batchSize int, fn func(row interface{}) error) error {
+	oc, op := c.startTableOperation("IterateCursor", s)
+	err := oc.iterateCursor(s, filter, batchSize, fn)
+	op.finish(err)
+	return err
+}
+
+func (c *CORM) iterateCursor(s interface{}, filter Filters, batchSize int, fn func(row interface{}) error) error {
+	table, err := c.GetTable(s)
+	if err != nil {
+		return err
+	}
+	q, err := c.newSelectQuery(table, filter, false)
+	if err != nil {
+		return err
+	}
+	if batchSize <= 0 {
+		batchSize = defaultCursorBatchSize
+	}
+
+	// cursor lives until the end of transaction, statements are not prepared as cursor names are unique
+	var ownTx *sql.Tx
+	tr := c.withoutStatementCache()
+	if c.tx == nil {
+		ownTx, err = c.db.BeginTx(c.getContext(), nil)
+		if err != nil {
+			return err
+		}
+		tr = tr.WithTx(ownTx)
+		defer tr.Rollback()
+	}
+
+	name := fmt.Sprintf("customorm_cursor_%d", atomic.AddUint64(&cursorCounter, 1))
+	_, err = tr.exec(fmt.Sprintf("DECLARE %s NO SCROLL CURSOR FOR %s;", name, q.selectSql()), q.args...)
+	if err != nil {
+		return err
+	}
+	scanner := newRowScanner(table, q.fnames)
+	fetchSql := fmt.Sprintf("FETCH FORWARD %d FROM %s;", batchSize, name)
+	for {
+		batch, err := tr.fetchBatch(fetchSql, scanner)
+		if err != nil {
+			return err
+		}
+		// rows of the batch are closed, so hooks and fn can use the transaction
+		for _, row := range batch {
+			err = tr.runHook(hookAfterFind, row.Addr().Interface())
+			if err != nil {
+				return err
+			}
+			err = fn(row.Interface())
+			if err != nil {
+				return err
+			}
+		}
+		if len(batch) < batchSize {
+			break
+		}
+	}
+	_, err = tr.exec(fmt.Sprintf("CLOSE %s;", name))
+	if err != nil {
+		return err
+	}
+	if ownTx != nil {
+		return tr.Commit()
+	}
+	return nil
+}
+
+// fetchBatch reads rows fetched from server-side cursor
+func (c *CORM) fetchBatch(fetchSql string, scanner *rowScanner) (batch []reflect.Value, err error) {
+	results, st, err := c.query(fetchSql)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+	defer func() { st.finish(int64(len(batch)), err) }()
+	for results.Next() {
+		var row reflect.Value
+		row, err = scanner.scan(results)
+		if err != nil {
+			return nil, err
+		}
+		batch = append(batch, row)
+	}
+	err = results.Err()
+	return batch, err
+}
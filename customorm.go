@@ -1,6 +1,7 @@
 package customorm
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
@@ -10,6 +11,9 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
+
+	"github.com/lib/pq"
 )
 
 /*
@@ -26,6 +30,16 @@ ENDING:
 	;position - field for order position value
 	;default= - default value after = sign
 	;check() - check constrain
+	;enum=name(a,b) - PostgreSQL enum type with values, or just enum=name for types implementing Enum
+	;type= - column type after = sign, overrides registered and built-in types
+	;tz - TIMESTAMPTZ column for time field
+	;date - DATE column for time field
+	;time - TIME column for time field
+	;createdat - creation time set by database on insert
+	;updatedat - modification time set by database on insert and every update
+	;softdelete - nullable deletion time, turns deletes into updates
+	;version - row version for optimistic locking, incremented by every update
+	;tenant - int64 or string tenant id scoping rows to the tenant of CORM.ForTenant
 */
 
 // Constants defining various tags and operands
@@ -38,6 +52,16 @@ const (
 	positionTag     = "position"
 	defaultTag      = "default"
 	checkTag        = "check"
+	enumTag         = "enum="
+	typeTag         = "type="
+	tzTag           = "tz"
+	dateTag         = "date"
+	timeTag         = "time"
+	createdAtTag    = "createdat"
+	updatedAtTag    = "updatedat"
+	softDeleteTag   = "softdelete"
+	versionTag      = "version"
+	tenantTag       = "tenant"
 	OperandEqual    = "="
 	OperandMore     = ">"
 	OperandLess     = "<"
@@ -48,24 +72,37 @@ const (
 
 // Table struct representing a database table
 type Table struct {
-	Name     string
+	Name string
+	// Schema is PostgreSQL schema of the table, empty for search_path of the connection
+	Schema   string
 	Columns  []Column
 	FKeys    []FKey
 	Uniq     []CompositeFields
 	Index    []CompositeFields
 	Instance interface{}
+	// tenant condition is added to write statements
+	tenantScoped bool
 }
 
 // Column struct representing a column in a database table
 type Column struct {
-	Name       string
-	FieldName  string
-	Value      interface{}
-	Type       string
-	Attr       string
-	IsPosition bool
-	Default    string
-	Check      string
+	Name         string
+	FieldName    string
+	Value        interface{}
+	Type         string
+	Attr         string
+	IsPosition   bool
+	Default      string
+	Check        string
+	EnumValues   []string
+	IsCreated    bool
+	IsUpdated    bool
+	IsSoftDelete bool
+	IsVersion    bool
+	IsTenant     bool
+	fieldIndex   int
+	// schema of enum type, the schema of the table
+	enumSchema string
 }
 
 // FKey struct representing a foreign key constraint
@@ -73,20 +110,32 @@ type FKey struct {
 	ColumnName      string
 	ColumnValue     interface{}
 	TableName       string
+	TableSchema     string
 	TableColumnName string
 	Type            reflect.Type
 	FieldName       string
 	IsNull          bool
+	fieldIndex      int
 }
 
 // Filters struct to hold filtering criteria for querying
 type Filters struct {
 	Fields map[string]FilterFields
-	Order  Order
-	Limit  int
-	Offset int
-	Count  bool
-	Error  error
+	// Select limits selected columns to the field names, all columns are selected if empty
+	Select []string
+	// Distinct removes duplicate rows of selected columns
+	Distinct bool
+	// DistinctOn keeps first row of each group of the field names, fields must lead the order
+	DistinctOn []string
+	// Joins of tables referenced by foreign key fields
+	Joins []Join
+	// Subqueries of tables related by foreign keys
+	Subqueries []Subquery
+	Order      Order
+	Limit      int
+	Offset     int
+	Count      bool
+	Error      error
 }
 
 // CompositeFields struct for field names could be composed wth others
@@ -111,28 +160,76 @@ type Order struct {
 
 // CORM is the main struct for Custom ORM
 type CORM struct {
-	db *sql.DB
+	db               *sql.DB
+	ctx              context.Context
+	tx               *sql.Tx
+	timestampTZ      bool
+	deletedScope     int
+	logger           Logger
+	slowThreshold    time.Duration
+	logArgs          bool
+	tracer           Tracer
+	op               *operation
+	metrics          *metricsRegistry
+	metricsCollector MetricsCollector
+	stmts            *statementCache
+	readCache        *readCache
+	maxRows          int
+	strictRowLimit   bool
+	schema           string
+	tenant           interface{}
+	txWrites         *txWrites
+	txStmts          *txStatements
 }
 
 // Init initializes the CORM instance with a database connection
 func Init(db *sql.DB) *CORM {
 	return &CORM{
-		db: db,
+		db:      db,
+		metrics: newMetricsRegistry(),
 	}
 }
 
 // GetTable retrieves the table structure based on the provided instance
 func (c *CORM) GetTable(s interface{}) (Table, error) {
-	tableName := GetTableName(s)
-	if tableName == "" {
-		return Table{}, errors.New("no table name")
-	}
 	direct := valueIfPtr(s)
-	if s == nil {
+	if direct == nil {
+		if GetTableName(s) == "" {
+			return Table{}, errors.New("no table name")
+		}
 		return Table{}, errors.New("no table instance")
 	}
-	table := Table{Name: tableName, Instance: direct}
-	table.ImportTableData()
+	schema, ok := tableSchemas.Load(reflect.TypeOf(direct))
+	if !ok {
+		tableName := GetTableName(s)
+		if tableName == "" {
+			return Table{}, errors.New("no table name")
+		}
+		schema = getTableSchema(reflect.TypeOf(direct), tableName)
+	}
+	var err error
+	ts := schema.(*tableSchema)
+	table := ts.newTable(direct)
+	table.Schema, err = c.resolveSchemaName(ts.schemaName)
+	if err != nil {
+		return Table{}, err
+	}
+	for i := range table.Columns {
+		table.Columns[i].enumSchema = table.Schema
+	}
+	for i := range table.FKeys {
+		table.FKeys[i].TableSchema, err = c.resolveSchemaName(ts.fKeySchemaNames[i])
+		if err != nil {
+			return Table{}, err
+		}
+	}
+	if c.timestampTZ {
+		for i := range table.Columns {
+			if table.Columns[i].Type == timestampType {
+				table.Columns[i].Type = timestampTZType
+			}
+		}
+	}
 	return table, nil
 }
 
@@ -156,6 +253,14 @@ func (table *Table) ImportTableData() {
 		ending := " NOT NULL"
 		defaultValue := ""
 		checkValue := ""
+		enumValue := ""
+		typeValue := ""
+		timeValue := ""
+		isCreated := false
+		isUpdated := false
+		isSoftDelete := false
+		isVersion := false
+		isTenant := false
 		subConstrain := strings.Split(tag, ";")
 		subOption := strings.Split(subConstrain[0], ":")
 		tag = subOption[0]
@@ -176,6 +281,7 @@ func (table *Table) ImportTableData() {
 					Type:            v.Field(i).Type().Elem(),
 					FieldName:       field.Name,
 					IsNull:          false,
+					fieldIndex:      i,
 				},
 				)
 				isFKey = true
@@ -191,6 +297,32 @@ func (table *Table) ImportTableData() {
 					ending = ""
 				case subConstrain[i] == positionTag:
 					isPosition = true
+				case subConstrain[i] == createdAtTag:
+					isCreated = true
+				case subConstrain[i] == updatedAtTag:
+					isUpdated = true
+				case subConstrain[i] == softDeleteTag:
+					isSoftDelete = true
+				case subConstrain[i] == versionTag:
+					isVersion = true
+				case subConstrain[i] == tenantTag:
+					isTenant = true
+				case subConstrain[i] == tzTag:
+					timeValue = timestampTZType
+				case subConstrain[i] == dateTag:
+					timeValue = dateType
+				case subConstrain[i] == timeTag:
+					timeValue = timeType
+				case strings.HasPrefix(subConstrain[i], enumTag):
+					enumValue = strings.TrimPrefix(subConstrain[i], enumTag)
+					if enumValue == "" {
+						panicErr(errors.New("enum arg have wrong format. table:" + table.Name + ". column: " + tag))
+					}
+				case strings.HasPrefix(subConstrain[i], typeTag):
+					typeValue = strings.TrimPrefix(subConstrain[i], typeTag)
+					if typeValue == "" {
+						panicErr(errors.New("type arg have wrong format. table:" + table.Name + ". column: " + tag))
+					}
 				case len(strings.Split(subConstrain[i], "default=")) > 1:
 					if strings.Split(subConstrain[i], "default=")[1] == "" {
 						panicErr(errors.New("default arg have wrong format. table:" + table.Name + ". column: " + tag))
@@ -213,40 +345,106 @@ func (table *Table) ImportTableData() {
 			continue
 		}
 		column := Column{
-			Name:       tag,
-			Value:      v.Field(i).Interface(),
-			Attr:       ending,
-			FieldName:  field.Name,
-			IsPosition: isPosition,
-			Default:    defaultValue,
-			Check:      checkValue,
+			Name:         tag,
+			Value:        columnValue(v.Field(i)),
+			Attr:         ending,
+			FieldName:    field.Name,
+			IsPosition:   isPosition,
+			Default:      defaultValue,
+			Check:        checkValue,
+			IsCreated:    isCreated,
+			IsUpdated:    isUpdated,
+			IsSoftDelete: isSoftDelete,
+			IsVersion:    isVersion,
+			IsTenant:     isTenant,
+			fieldIndex:   i,
 		}
 
-		switch field.Type.Name() {
-		case "bool":
-			column.Type = "BOOLEAN"
-		case "int64":
-			if isSerial {
-				column.Type = "SERIAL"
-			} else {
-				column.Type = "BIGINT"
-			}
-		case "int", "uint":
-			column.Type = "INTEGER"
-		case "string":
-			column.Type = "VARCHAR"
-		case "float32", "float64":
-			column.Type = "FLOAT"
-		case "time.Time", "Time":
-			column.Type = "TIMESTAMP"
-		default:
+		enumName, enumValues, err := enumDefinition(field.Type, enumValue)
+		if err != nil {
+			panicErr(errors.New(err.Error() + ". table:" + table.Name + ". column: " + tag))
+		}
+		if enumName != "" {
+			column.Type = enumName
+			column.EnumValues = enumValues
+			table.Columns = append(table.Columns, column)
+			continue
+		}
+
+		column.Type = typeValue
+		if column.Type == "" {
+			column.Type = fieldColumnType(field.Type, isSerial)
+		}
+		if column.Type == "" {
 			log.Printf("Unknown type for column %s: %s", column.Name, field.Type.Name())
 			continue
 		}
+		if timeValue != "" {
+			if !isTimeType(field.Type) {
+				panicErr(errors.New(timeValue + " arg used for not time field. table:" + table.Name + ". column: " + tag))
+			}
+			column.Type = timeValue
+		}
+		if column.isTimestampColumn() {
+			column.Value = utcValue(column.Value)
+		}
+		if column.IsCreated || column.IsUpdated {
+			if field.Type != timeValueType {
+				panicErr(errors.New("createdat and updatedat args used for not time.Time field. table:" + table.Name + ". column: " + tag))
+			}
+			if column.Default == "" {
+				column.Default = "DEFAULT now()"
+			}
+		}
+		if column.IsSoftDelete {
+			if field.Type != nullTimeType {
+				panicErr(errors.New("softdelete arg used for not sql.NullTime field. table:" + table.Name + ". column: " + tag))
+			}
+			column.Attr = ""
+		}
+		if column.IsVersion {
+			if field.Type.Kind() != reflect.Int64 {
+				panicErr(errors.New("version arg used for not int64 field. table:" + table.Name + ". column: " + tag))
+			}
+			if column.Default == "" {
+				column.Default = "DEFAULT 1"
+			}
+		}
+		if column.IsTenant && field.Type.Kind() != reflect.Int64 && field.Type.Kind() != reflect.String {
+			panicErr(errors.New("tenant arg used for not int64 or string field. table:" + table.Name + ". column: " + tag))
+		}
 		table.Columns = append(table.Columns, column)
 	}
 }
 
+// fieldColumnType returns SQL column type for the Go field type or empty string if type is unknown
+func fieldColumnType(t reflect.Type, isSerial bool) string {
+	if sqlType, ok := registeredType(t); ok {
+		return sqlType
+	}
+	switch t.Name() {
+	case "bool":
+		return "BOOLEAN"
+	case "int64":
+		if isSerial {
+			return "SERIAL"
+		}
+		return "BIGINT"
+	case "int", "uint":
+		return "INTEGER"
+	case "string":
+		return "VARCHAR"
+	case "float32", "float64":
+		return "FLOAT"
+	case "time.Time", "Time":
+		return timestampType
+	}
+	if isValuerScanner(t) {
+		return defaultCustomType
+	}
+	return ""
+}
+
 // valueIfPtr returns the value if the input is not a pointer, otherwise returns the dereferenced value
 func valueIfPtr(s interface{}) interface{} {
 	if s == nil {
@@ -342,8 +540,13 @@ func GetTableName(i interface{}) string {
 	return name
 }
 
+var (
+	tableNameRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]{0,63}$`)
+	matchFirstCap  = regexp.MustCompile("([A-Za-z]+)([A-Z][a-z]+)")
+	matchAllCap    = regexp.MustCompile("([a-z0-9])([A-Z])")
+)
+
 func isValidTableName(name string) bool {
-	tableNameRegex := regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]{0,63}$`)
 	return tableNameRegex.MatchString(name)
 }
 
@@ -358,9 +561,6 @@ func getType(myvar interface{}) string {
 
 // ToSnakeCase converts a string to snake case
 func ToSnakeCase(str string) string {
-	matchFirstCap := regexp.MustCompile("([A-Za-z]+)([A-Z][a-z]+)")
-	matchAllCap := regexp.MustCompile("([a-z0-9])([A-Z])")
-
 	snake := matchFirstCap.ReplaceAllString(str, "${1}_${2}")
 	snake = matchAllCap.ReplaceAllString(snake, "${1}_${2}")
 	return strings.ToLower(snake)
@@ -376,7 +576,7 @@ func (c *Column) toString() string {
 	if c.Name == "" || c.Type == "" {
 		return ""
 	}
-	return fmt.Sprintf("%s %s %s %s %s", c.Name, c.Type, c.Attr, c.Default, c.Check)
+	return fmt.Sprintf("%s %s %s %s %s", pq.QuoteIdentifier(c.Name), c.sqlType(), c.Attr, c.Default, c.Check)
 }
 
 func (f *FKey) toString() string {
@@ -389,7 +589,7 @@ func (f *FKey) toString() string {
 		inNull = ""
 		onDelete = "SET NULL"
 	}
-	return fmt.Sprintf("%s bigint %s REFERENCES %s (%s) ON DELETE %s", f.ColumnName, inNull, f.TableName, f.TableColumnName, onDelete)
+	return fmt.Sprintf("%s bigint %s REFERENCES %s (%s) ON DELETE %s", pq.QuoteIdentifier(f.ColumnName), inNull, qualifiedName(f.TableSchema, f.TableName), pq.QuoteIdentifier(f.TableColumnName), onDelete)
 }
 
 func (table *Table) createTableSql() (string, []string) {
@@ -404,7 +604,7 @@ func (table *Table) createTableSql() (string, []string) {
 			if len(u) == 0 {
 				continue
 			}
-			uniqLines = ",\n UNIQUE (" + strings.Join(u, ", ") + ")"
+			uniqLines = ",\n UNIQUE (" + strings.Join(quoteNames(u), ", ") + ")"
 		}
 	}
 	var indexLines []string
@@ -414,7 +614,8 @@ func (table *Table) createTableSql() (string, []string) {
 			if len(i) == 0 {
 				continue
 			}
-			indexLines = append(indexLines, "CREATE INDEX IF NOT EXISTS idx_"+table.Name+"_"+strings.Join(i, "_")+" ON "+table.Name+"("+strings.Join(i, ", ")+")")
+			indexName := pq.QuoteIdentifier("idx_" + table.Name + "_" + strings.Join(i, "_"))
+			indexLines = append(indexLines, "CREATE INDEX IF NOT EXISTS "+indexName+" ON "+table.quotedName()+"("+strings.Join(quoteNames(i), ", ")+")")
 		}
 	}
 
@@ -436,7 +637,7 @@ func (table *Table) createTableSql() (string, []string) {
 		%s%s%s
 	)
 	WITH (OIDS=FALSE);`,
-		table.Name, fKeySQL.String(), columnsSQL.String(), uniqLines)
+		table.quotedName(), fKeySQL.String(), columnsSQL.String(), uniqLines)
 	//log.Println(sqlReq)
 	return sqlReq, indexLines
 }
@@ -0,0 +1,187 @@
+package customorm
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// Enum interface to get allowed values of a field type stored as PostgreSQL enum
+type Enum interface {
+	EnumValues() []string
+}
+
+var enumType = reflect.TypeOf((*Enum)(nil)).Elem()
+
+// enumDefinition resolves enum type name and values from the field type and enum= tag value
+func enumDefinition(fieldType reflect.Type, tagValue string) (string, []string, error) {
+	var name string
+	var values []string
+	isEnum := tagValue != ""
+
+	if tagValue != "" {
+		name = tagValue
+		if idx := strings.Index(tagValue, "("); idx != -1 {
+			if tagValue[len(tagValue)-1] != ')' {
+				return "", nil, errors.New("enum arg have wrong format")
+			}
+			name = tagValue[:idx]
+			for _, v := range strings.Split(tagValue[idx+1:len(tagValue)-1], ",") {
+				v = strings.TrimSpace(v)
+				if v == "" {
+					continue
+				}
+				values = append(values, v)
+			}
+		}
+	}
+
+	if len(values) == 0 {
+		var instance interface{}
+		switch {
+		case fieldType.Implements(enumType):
+			instance = reflect.Zero(fieldType).Interface()
+		case reflect.PtrTo(fieldType).Implements(enumType):
+			instance = reflect.New(fieldType).Interface()
+		}
+		if e, ok := instance.(Enum); ok {
+			isEnum = true
+			values = e.EnumValues()
+		}
+	}
+	if !isEnum {
+		return "", nil, nil
+	}
+
+	if name == "" {
+		name = ToSnakeCase(fieldType.Name())
+	}
+	if !isValidTableName(name) {
+		return "", nil, errors.New("enum name have wrong format: " + name)
+	}
+	if fieldType.Kind() != reflect.String {
+		return "", nil, errors.New("enum field must be a string type: " + name)
+	}
+	if len(values) == 0 {
+		return "", nil, errors.New("no enum values: " + name)
+	}
+	return name, values, nil
+}
+
+// createEnumSql generates statement creating enum type if it does not exist yet
+func (c *Column) createEnumSql() string {
+	if len(c.EnumValues) == 0 {
+		return ""
+	}
+	quoted := make([]string, 0, len(c.EnumValues))
+	for _, v := range c.EnumValues {
+		quoted = append(quoted, pq.QuoteLiteral(v))
+	}
+	return fmt.Sprintf(`DO $$ BEGIN
+		CREATE TYPE %s AS ENUM (%s);
+	EXCEPTION
+		WHEN duplicate_object THEN null;
+	END $$;`, c.sqlType(), strings.Join(quoted, ", "))
+}
+
+// addEnumValuesSql generates statements adding values missing in existing enum type keeping declared order
+func (c *Column) addEnumValuesSql(existing []string) []string {
+	var known = make(map[string]bool, len(existing))
+	for _, v := range existing {
+		known[v] = true
+	}
+	var res []string
+	for i, v := range c.EnumValues {
+		if known[v] {
+			continue
+		}
+		after := ""
+		if i > 0 {
+			after = " AFTER " + pq.QuoteLiteral(c.EnumValues[i-1])
+		}
+		res = append(res, fmt.Sprintf("ALTER TYPE %s ADD VALUE IF NOT EXISTS %s%s;", c.sqlType(), pq.QuoteLiteral(v), after))
+	}
+	return res
+}
+
+// MigrateEnums creates enum types used by the table and adds newly declared values to existing ones
+func (c *CORM) MigrateEnums(s interface{}) error {
+	oc, op := c.startTableOperation("MigrateEnums", s)
+	err := oc.migrateEnums(s)
+	op.finish(err)
+	return err
+}
+
+func (c *CORM) migrateEnums(s interface{}) error {
+	table, err := c.GetTable(s)
+	if err != nil {
+		return err
+	}
+	if !table.hasEnums() {
+		return nil
+	}
+	return c.schemaChange(func(sc *CORM) error {
+		err := sc.createSchema(table)
+		if err != nil {
+			return err
+		}
+		return sc.migrateTableEnums(table)
+	})
+}
+
+// hasEnums checks if the table has enum columns
+func (table *Table) hasEnums() bool {
+	for _, column := range table.Columns {
+		if len(column.EnumValues) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// migrateTableEnums creates enum types of the table columns and adds missing values, schema of the table must exist
+func (c *CORM) migrateTableEnums(table Table) error {
+	var err error
+	for _, column := range table.Columns {
+		if len(column.EnumValues) == 0 {
+			continue
+		}
+		_, err = c.exec(column.createEnumSql())
+		if err != nil {
+			return err
+		}
+
+		results, st, err := c.query(`SELECT enumlabel FROM pg_enum WHERE enumtypid = $1::regtype ORDER BY enumsortorder;`, column.sqlType())
+		if err != nil {
+			return err
+		}
+		var existing []string
+		for results.Next() {
+			var label string
+			err = results.Scan(&label)
+			if err != nil {
+				break
+			}
+			existing = append(existing, label)
+		}
+		results.Close()
+		if err == nil {
+			err = results.Err()
+		}
+		st.finish(int64(len(existing)), err)
+		if err != nil {
+			return err
+		}
+
+		for _, sqlReq := range column.addEnumValuesSql(existing) {
+			_, err = c.exec(sqlReq)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,12 @@
+package customorm
+
+import "errors"
+
+// ErrStaleObject is returned by UpdateRow when the row version was changed by another update
+var ErrStaleObject = errors.New("stale object: row was modified or deleted")
+
+// ErrResultTruncated is returned by readers in strict row limit mode when more rows exist than the maximum
+var ErrResultTruncated = errors.New("result truncated: more rows exist than the maximum rows limit")
+
+// ErrTenantMismatch is returned by writers scoped by ForTenant when the row belongs to another tenant or no row of the tenant is written
+var ErrTenantMismatch = errors.New("tenant mismatch: row belongs to another tenant")
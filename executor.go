@@ -0,0 +1,67 @@
+package customorm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// Executor interface of database connection or transaction running CORM statements
+type Executor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// WithContext returns CORM copy running statements with the given context
+func (c *CORM) WithContext(ctx context.Context) *CORM {
+	n := *c
+	n.ctx = ctx
+	return &n
+}
+
+// WithTx returns CORM copy running statements inside the given transaction.
+// The transaction is finished by Commit or Rollback of the copy, so cached reads of written tables are invalidated.
+func (c *CORM) WithTx(tx *sql.Tx) *CORM {
+	n := *c
+	n.tx = tx
+	n.txWrites = &txWrites{seen: map[string]bool{}}
+	n.txStmts = &txStatements{stmts: map[string]*sql.Stmt{}}
+	return &n
+}
+
+// Commit commits transaction of the WithTx copy and invalidates cached reads of tables written in it
+func (c *CORM) Commit() error {
+	if c.tx == nil {
+		return errors.New("no transaction")
+	}
+	err := c.tx.Commit()
+	c.txWrites.finish()
+	return err
+}
+
+// Rollback rolls back transaction of the WithTx copy and invalidates cached reads of tables written in it
+func (c *CORM) Rollback() error {
+	if c.tx == nil {
+		return errors.New("no transaction")
+	}
+	err := c.tx.Rollback()
+	c.txWrites.finish()
+	return err
+}
+
+// getContext returns context of the statements
+func (c *CORM) getContext() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
+
+// executor returns active transaction or database connection
+func (c *CORM) executor() Executor {
+	if c.tx != nil {
+		return c.tx
+	}
+	return c.db
+}
@@ -0,0 +1,116 @@
+package customorm
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strconv"
+	"sync"
+)
+
+// fakeDriver is database/sql driver accepting any statement, by default queries return single row with id 1
+type fakeDriver struct{}
+
+// fakeDB configures results of database opened by openFakeDBWith and records its statements
+type fakeDB struct {
+	mu sync.Mutex
+	// rowsAffected is result of Exec statements
+	rowsAffected int64
+	// rows returns columns and rows of the query
+	rows func(query string) ([]string, [][]driver.Value)
+	// queries holds statements run on the database
+	queries []string
+}
+
+type fakeConn struct {
+	db *fakeDB
+}
+
+type fakeStmt struct {
+	db    *fakeDB
+	query string
+}
+
+type fakeTx struct{}
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+var (
+	registerFakeDriver sync.Once
+	fakeDBsMu          sync.Mutex
+	fakeDBs            = map[string]*fakeDB{}
+)
+
+// openFakeDB returns database handle using fakeDriver
+func openFakeDB() *sql.DB {
+	return openFakeDBWith(&fakeDB{rowsAffected: 1})
+}
+
+// openFakeDBWith returns database handle using fakeDriver with the given results
+func openFakeDBWith(f *fakeDB) *sql.DB {
+	registerFakeDriver.Do(func() { sql.Register("customorm_fake", fakeDriver{}) })
+	fakeDBsMu.Lock()
+	name := strconv.Itoa(len(fakeDBs))
+	fakeDBs[name] = f
+	fakeDBsMu.Unlock()
+	db, err := sql.Open("customorm_fake", name)
+	panicErr(err)
+	return db
+}
+
+// statements returns statements run on the database
+func (f *fakeDB) statements() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.queries...)
+}
+
+func (f *fakeDB) record(query string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.queries = append(f.queries, query)
+}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	fakeDBsMu.Lock()
+	defer fakeDBsMu.Unlock()
+	return fakeConn{db: fakeDBs[name]}, nil
+}
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return fakeStmt{db: c.db, query: query}, nil
+}
+func (fakeConn) Close() error              { return nil }
+func (fakeConn) Begin() (driver.Tx, error) { return fakeTx{}, nil }
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+func (fakeStmt) Close() error  { return nil }
+func (fakeStmt) NumInput() int { return -1 }
+func (s fakeStmt) Exec([]driver.Value) (driver.Result, error) {
+	s.db.record(s.query)
+	return driver.RowsAffected(s.db.rowsAffected), nil
+}
+func (s fakeStmt) Query([]driver.Value) (driver.Rows, error) {
+	s.db.record(s.query)
+	if s.db.rows == nil {
+		return &fakeRows{columns: []string{"id"}, rows: [][]driver.Value{{int64(1)}}}, nil
+	}
+	columns, rows := s.db.rows(s.query)
+	return &fakeRows{columns: columns, rows: rows}, nil
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (*fakeRows) Close() error        { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
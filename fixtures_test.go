@@ -0,0 +1,32 @@
+package customorm
+
+import (
+	"database/sql"
+	"time"
+)
+
+// testDomain and testDomainUser are table structs shared by tests
+type testDomain struct {
+	Id      int64  `json:"id" customsql:"pkey:id;check(id <> 0)"`
+	Enabled bool   `json:"enabled" customsql:"enabled;default=TRUE"`
+	Name    string `json:"name" customsql:"name;unique;check(name <> '')"`
+}
+
+func (d *testDomain) GetTableName() string {
+	return "domains"
+}
+
+type testDomainUser struct {
+	Id        int64        `json:"id" customsql:"pkey:id;check(id <> 0)"`
+	Position  int64        `json:"position" customsql:"position;position"`
+	Enabled   bool         `json:"enabled" customsql:"enabled;default=TRUE"`
+	Name      string       `json:"name" customsql:"name;unique_1;check(name <> '')"`
+	UpdatedAt time.Time    `json:"updated_at" customsql:"updated_at;updatedat"`
+	DeletedAt sql.NullTime `json:"deleted_at" customsql:"deleted_at;softdelete"`
+	Version   int64        `json:"version" customsql:"version;version"`
+	Parent    *testDomain  `json:"parent" customsql:"fkey:parent_id;unique_1;check(parent_id <> 0)"`
+}
+
+func (d *testDomainUser) GetTableName() string {
+	return "domain_users"
+}
@@ -0,0 +1,87 @@
+package customorm
+
+import (
+	"context"
+)
+
+// BeforeInsert interface of structs called by InsertRow before the row is inserted
+type BeforeInsert interface {
+	BeforeInsert(ctx context.Context, exec Executor) error
+}
+
+// AfterInsert interface of structs called by InsertRow after the row is inserted
+type AfterInsert interface {
+	AfterInsert(ctx context.Context, exec Executor) error
+}
+
+// BeforeUpdate interface of structs called by UpdateRow before the row is updated
+type BeforeUpdate interface {
+	BeforeUpdate(ctx context.Context, exec Executor) error
+}
+
+// AfterUpdate interface of structs called by UpdateRow after the row is updated
+type AfterUpdate interface {
+	AfterUpdate(ctx context.Context, exec Executor) error
+}
+
+// BeforeDelete interface of structs called by DeleteRowById and DeleteRows before rows are deleted
+type BeforeDelete interface {
+	BeforeDelete(ctx context.Context, exec Executor) error
+}
+
+// AfterDelete interface of structs called by DeleteRowById and DeleteRows after rows are deleted
+type AfterDelete interface {
+	AfterDelete(ctx context.Context, exec Executor) error
+}
+
+// AfterFind interface of structs called by readers for every loaded row
+type AfterFind interface {
+	AfterFind(ctx context.Context, exec Executor) error
+}
+
+// Kinds of lifecycle hooks
+const (
+	hookBeforeInsert = iota
+	hookAfterInsert
+	hookBeforeUpdate
+	hookAfterUpdate
+	hookBeforeDelete
+	hookAfterDelete
+	hookAfterFind
+)
+
+// runHook calls lifecycle hook if the struct implements it, returned error aborts the operation
+func (c *CORM) runHook(kind int, s interface{}) error {
+	ctx, exec := c.getContext(), c.executor()
+	switch kind {
+	case hookBeforeInsert:
+		if h, ok := s.(BeforeInsert); ok {
+			return h.BeforeInsert(ctx, exec)
+		}
+	case hookAfterInsert:
+		if h, ok := s.(AfterInsert); ok {
+			return h.AfterInsert(ctx, exec)
+		}
+	case hookBeforeUpdate:
+		if h, ok := s.(BeforeUpdate); ok {
+			return h.BeforeUpdate(ctx, exec)
+		}
+	case hookAfterUpdate:
+		if h, ok := s.(AfterUpdate); ok {
+			return h.AfterUpdate(ctx, exec)
+		}
+	case hookBeforeDelete:
+		if h, ok := s.(BeforeDelete); ok {
+			return h.BeforeDelete(ctx, exec)
+		}
+	case hookAfterDelete:
+		if h, ok := s.(AfterDelete); ok {
+			return h.AfterDelete(ctx, exec)
+		}
+	case hookAfterFind:
+		if h, ok := s.(AfterFind); ok {
+			return h.AfterFind(ctx, exec)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+package customorm
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// Join types
+const (
+	JoinInner = "INNER"
+	JoinLeft  = "LEFT"
+)
+
+// mainTableAlias is alias of the queried table in statements with joins
+const mainTableAlias = "t"
+
+// Join struct defining join of the table referenced by foreign key field.
+// Filters and order fields of joined table are prefixed by the foreign key field name, e.g. "Parent.Name".
+type Join struct {
+	// Field is name of the foreign key field
+	Field string
+	// Type is JoinInner or JoinLeft, default JoinInner
+	Type string
+	// Alias of joined table in statement, default is the field name in snake case
+	Alias string
+}
+
+// InnerJoin adds inner join of the table referenced by foreign key field
+func (f *Filters) InnerJoin(field string) *Filters {
+	if f.Error != nil {
+		return f
+	}
+	f.Joins = append(f.Joins, Join{Field: field, Type: JoinInner})
+	return f
+}
+
+// LeftJoin adds left join of the table referenced by foreign key field
+func (f *Filters) LeftJoin(field string) *Filters {
+	if f.Error != nil {
+		return f
+	}
+	f.Joins = append(f.Joins, Join{Field: field, Type: JoinLeft})
+	return f
+}
+
+// selectJoin holds joined table of select query
+type selectJoin struct {
+	kind      string
+	alias     string
+	fKey      FKey
+	table     Table
+	condition string
+}
+
+// column returns quoted column name qualified by the query table alias
+func (q *selectQuery) column(name string) string {
+	if q.alias == "" {
+		return pq.QuoteIdentifier(name)
+	}
+	return pq.QuoteIdentifier(q.alias) + "." + pq.QuoteIdentifier(name)
+}
+
+// column returns quoted column name qualified by the joined table alias
+func (j *selectJoin) column(name string) string {
+	return pq.QuoteIdentifier(j.alias) + "." + pq.QuoteIdentifier(name)
+}
+
+// from returns FROM clause source of the query with joined tables
+func (q *selectQuery) from() string {
+	if q.alias == "" {
+		return q.table.quotedName()
+	}
+	from := q.table.quotedName() + " AS " + pq.QuoteIdentifier(q.alias)
+	for _, j := range q.joins {
+		from += fmt.Sprintf(" %s JOIN %s AS %s ON %s = %s", j.kind, j.table.quotedName(), pq.QuoteIdentifier(j.alias), j.column(j.fKey.TableColumnName), q.column(j.fKey.ColumnName))
+		if j.condition != "" {
+			from += " AND " + j.condition
+		}
+	}
+	return from
+}
+
+// fieldColumn returns qualified column name of the struct field name or of joined table field like "Parent.Name"
+func (q *selectQuery) fieldColumn(field string) (string, bool) {
+	if i := strings.Index(field, "."); i > 0 {
+		for _, j := range q.joins {
+			if j.fKey.FieldName != field[:i] {
+				continue
+			}
+			name, ok := columnOfField(j.table, field[i+1:])
+			if !ok {
+				return "", false
+			}
+			return j.column(name), true
+		}
+		return "", false
+	}
+	name, ok := columnOfField(q.table, field)
+	if !ok {
+		return "", false
+	}
+	return q.column(name), true
+}
+
+// addJoins adds joined tables of the filter to the query
+func (c *CORM) addJoins(q *selectQuery, filter Filters) error {
+	if len(filter.Joins) == 0 {
+		return nil
+	}
+	if q.alias == "" {
+		q.alias = mainTableAlias
+	}
+	var aliases = map[string]bool{q.alias: true}
+	indirect := reflect.ValueOf(q.table.Instance)
+	for _, join := range filter.Joins {
+		var fKey *FKey
+		for i := range q.table.FKeys {
+			if q.table.FKeys[i].FieldName == join.Field {
+				fKey = &q.table.FKeys[i]
+			}
+		}
+		if fKey == nil {
+			return fmt.Errorf("unknown join field %s of table %s", join.Field, q.table.Name)
+		}
+		kind := join.Type
+		switch kind {
+		case "":
+			kind = JoinInner
+		case JoinInner, JoinLeft:
+		default:
+			return fmt.Errorf("unknown join type %s", join.Type)
+		}
+		alias := join.Alias
+		if alias == "" {
+			alias = ToSnakeCase(join.Field)
+		}
+		if aliases[alias] || !tableNameRegex.MatchString(alias) {
+			return fmt.Errorf("invalid join alias %s", alias)
+		}
+		aliases[alias] = true
+
+		// values of referenced struct are used as filter values
+		instance := reflect.New(fKey.Type).Interface()
+		if ref := indirect.FieldByName(fKey.FieldName); ref.Kind() == reflect.Ptr && !ref.IsNil() {
+			instance = ref.Interface()
+		}
+		joined, err := c.GetTable(instance)
+		if err != nil {
+			return err
+		}
+		j := selectJoin{kind: kind, alias: alias, fKey: *fKey, table: joined}
+		// soft deleted rows of joined table are skipped in default scope
+		var conditions []string
+		if column := joined.softDeleteColumn(); column != nil && c.deletedScope == deletedScopeExclude {
+			conditions = append(conditions, j.column(column.Name)+" IS NULL")
+		}
+		condition, err := c.joinTenantCondition(q, &j)
+		if err != nil {
+			return err
+		}
+		if condition != "" {
+			conditions = append(conditions, condition)
+		}
+		j.condition = strings.Join(conditions, " AND ")
+		q.joins = append(q.joins, j)
+		q.related = true
+	}
+	return nil
+}
+
+// addJoinFilters adds conditions of filters referencing joined table fields
+func (q *selectQuery) addJoinFilters(filter Filters) {
+	for _, j := range q.joins {
+		prefix := j.fKey.FieldName + "."
+		for _, v := range j.table.Columns {
+			fName, val, operand, postOperand := getFilterParams(filter, prefix+v.FieldName, prefix+v.Name, v.Value)
+			if fName == "" {
+				continue
+			}
+			q.wheres = append(q.wheres, j.column(v.Name)+" "+operand+" "+q.arg(val)+postOperand)
+		}
+		for _, v := range j.table.FKeys {
+			fName, val, operand, postOperand := getFilterParams(filter, prefix+v.FieldName, prefix+v.ColumnName, v.ColumnValue)
+			if fName == "" {
+				continue
+			}
+			q.wheres = append(q.wheres, j.column(v.ColumnName)+" "+operand+" "+q.arg(val)+postOperand)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package customorm
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// RedactedArg replaces statement arguments passed to Logger unless SetLogArgs is enabled
+const RedactedArg = "[redacted]"
+
+// QueryEvent describes a statement executed by CORM
+type QueryEvent struct {
+	SQL          string
+	Args         []interface{}
+	Duration     time.Duration
+	RowsAffected int64
+	Err          error
+	Slow         bool
+}
+
+// Logger interface receiving every statement executed by CORM
+type Logger interface {
+	LogQuery(ctx context.Context, event QueryEvent)
+}
+
+// LoggerFunc adapts function to Logger interface
+type LoggerFunc func(ctx context.Context, event QueryEvent)
+
+// LogQuery calls the function
+func (f LoggerFunc) LogQuery(ctx context.Context, event QueryEvent) {
+	f(ctx, event)
+}
+
+// SetLogger sets logger receiving executed statements, nil disables logging
+func (c *CORM) SetLogger(logger Logger) *CORM {
+	c.logger = logger
+	return c
+}
+
+// SetSlowQueryThreshold sets duration after which statements are reported as slow
+func (c *CORM) SetSlowQueryThreshold(threshold time.Duration) *CORM {
+	c.slowThreshold = threshold
+	return c
+}
+
+// SetLogArgs enables passing real argument values to logger instead of RedactedArg
+func (c *CORM) SetLogArgs(enabled bool) *CORM {
+	c.logArgs = enabled
+	return c
+}
+
+// statement tracks execution of a single SQL statement
+type statement struct {
+	c     *CORM
+	ctx   context.Context
+	sql   string
+	args  []interface{}
+	start time.Time
+	span  Span
+}
+
+// startStatement begins tracking of the statement
+func (c *CORM) startStatement(query string, args []interface{}) *statement {
+	st := &statement{c: c, ctx: c.getContext(), sql: query, args: args, start: time.Now()}
+	if c.tracer != nil {
+		st.ctx, st.span = c.tracer.StartSpan(st.ctx, statementSpanName)
+		st.span.SetAttribute(AttrStatement, query)
+	}
+	return st
+}
+
+// finish reports statement result to the operation, tracer and logger
+func (s *statement) finish(rows int64, err error) {
+	if s.c.op != nil {
+		s.c.op.addRows(rows)
+	}
+	if s.span != nil {
+		s.span.SetAttribute(AttrRows, rows)
+		if err != nil {
+			s.span.RecordError(err)
+		}
+		s.span.End()
+	}
+	if s.c.logger == nil {
+		return
+	}
+	event := QueryEvent{
+		SQL:          s.sql,
+		Args:         s.args,
+		Duration:     time.Since(s.start),
+		RowsAffected: rows,
+		Err:          err,
+	}
+	if s.c.slowThreshold > 0 && event.Duration >= s.c.slowThreshold {
+		event.Slow = true
+	}
+	if !s.c.logArgs {
+		event.Args = make([]interface{}, len(s.args))
+		for i := range event.Args {
+			event.Args[i] = RedactedArg
+		}
+	}
+	s.c.logger.LogQuery(s.ctx, event)
+}
+
+// exec runs statement without returned rows
+func (c *CORM) exec(query string, args ...interface{}) (sql.Result, error) {
+	st := c.startStatement(query, args)
+	ex, release := c.executorFor(st.ctx, query)
+	res, err := ex.ExecContext(st.ctx, query, args...)
+	release()
+	var rows int64
+	if err == nil {
+		rows, _ = res.RowsAffected()
+	}
+	st.finish(rows, err)
+	return res, err
+}
+
+// queryRow runs statement returning single row and scans it into dest
+func (c *CORM) queryRow(query string, args []interface{}, dest ...interface{}) error {
+	st := c.startStatement(query, args)
+	ex, release := c.executorFor(st.ctx, query)
+	err := ex.QueryRowContext(st.ctx, query, args...).Scan(dest...)
+	release()
+	var rows int64
+	if err == nil {
+		rows = 1
+	}
+	st.finish(rows, err)
+	return err
+}
+
+// query runs statement returning rows, caller finishes the returned statement after reading them
+func (c *CORM) query(query string, args ...interface{}) (*sql.Rows, *statement, error) {
+	st := c.startStatement(query, args)
+	ex, release := c.executorFor(st.ctx, query)
+	results, err := ex.QueryContext(st.ctx, query, args...)
+	release()
+	if err != nil {
+		st.finish(0, err)
+		return nil, nil, err
+	}
+	return results, st, nil
+}
@@ -5,13 +5,18 @@ import (
 	"errors"
 	"fmt"
 	"github.com/lib/pq"
-	"log"
 	"reflect"
-	"strconv"
 	"strings"
+	"time"
 )
 
 func (c *CORM) CreateTable(s interface{}) bool {
+	oc, op := c.startTableOperation("CreateTable", s)
+	defer op.finishPanic()
+	return oc.createTable(s)
+}
+
+func (c *CORM) createTable(s interface{}) bool {
 	table, err := c.GetTable(s)
 	if err != nil {
 		panicErr(err)
@@ -22,24 +27,76 @@ func (c *CORM) CreateTable(s interface{}) bool {
 	if sqlReq == "" {
 		panicErr(errors.New("cant create table " + table.Name))
 	}
-	_, err = c.db.Exec(sqlReq)
-
+	err = c.schemaChange(func(sc *CORM) error {
+		err := sc.createSchema(table)
+		if err != nil {
+			return err
+		}
+		err = sc.migrateTableEnums(table)
+		if err != nil {
+			return err
+		}
+		_, err = sc.exec(sqlReq)
+		if err != nil {
+			return err
+		}
+		for _, s := range indexLines {
+			_, err = sc.exec(s)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 	panicErr(err)
-	for _, s := range indexLines {
-		_, err = c.db.Exec(s)
-		panicErr(err)
-	}
 
 	return true
 }
 
 func (c *CORM) InsertRow(s interface{}) (int64, error) {
-	table, err := c.GetTable(s)
+	oc, op := c.startTableOperation("InsertRow", s)
+	id, err := oc.insertRow(s)
+	c.invalidateReadCache(op.table)
+	op.finish(err)
+	return id, err
+}
+
+func (c *CORM) insertRow(s interface{}) (int64, error) {
+	err := c.runHook(hookBeforeInsert, s)
+	if err != nil {
+		return 0, err
+	}
+	table, err := c.writeTable(s)
+	if err != nil {
+		return 0, err
+	}
+	st, err := table.insertStatement()
 	if err != nil {
 		return 0, err
 	}
 
 	var id int64
+	err = c.queryRow(st.sql, st.args, append([]interface{}{&id}, st.returning.ptrs()...)...)
+	if err != nil {
+		return 0, err
+	}
+	if id == 0 {
+		return 0, errors.New("no new id returned")
+	}
+	st.returning.writeBack(s)
+	table.writeTenant(s)
+	setFieldValue(s, "Id", id)
+
+	err = c.runHook(hookAfterInsert, s)
+	if err != nil {
+		return id, err
+	}
+
+	return id, nil
+}
+
+// insertStatement generates statement inserting the table row
+func (table *Table) insertStatement() (*writeStatement, error) {
 	var names []string
 	var values []interface{}
 	var parentColumnName string
@@ -48,7 +105,7 @@ func (c *CORM) InsertRow(s interface{}) (int64, error) {
 	// Collect foreign key column names and values
 	for _, v := range table.FKeys {
 		if !v.IsNull && (v.ColumnValue == nil || v.ColumnValue == 0 || v.ColumnValue == "" || v.ColumnValue == false) {
-			return 0, errors.New("empty foreign key value")
+			return nil, errors.New("empty foreign key value")
 		}
 
 		if !v.IsNull {
@@ -62,15 +119,28 @@ func (c *CORM) InsertRow(s interface{}) (int64, error) {
 
 	var positionSql string
 	var positionColumnName string
+	st := &writeStatement{}
 
 	// Prepare position column SQL if necessary
 	for _, v := range table.Columns {
 		if v.Name == "id" {
 			continue
 		}
+		if v.IsVersion {
+			v.Value = int64(1)
+			st.returning.add(v, new(int64))
+		}
+		if v.IsCreated || v.IsUpdated {
+			st.returning.addTime(v)
+			// zero timestamps are left to database default
+			if t, _ := v.Value.(time.Time); t.IsZero() {
+				continue
+			}
+		}
 		if v.IsPosition {
 			positionColumnName = v.Name
-			positionSql = fmt.Sprintf("(SELECT COALESCE((SELECT %s + 1 FROM %s WHERE %s = $%d ORDER BY %s DESC LIMIT 1), 1))", v.Name, table.Name, parentColumnName, parentIndex, v.Name)
+			name := pq.QuoteIdentifier(v.Name)
+			positionSql = fmt.Sprintf("(SELECT COALESCE((SELECT %s + 1 FROM %s WHERE %s = $%d ORDER BY %s DESC LIMIT 1), 1))", name, table.quotedName(), pq.QuoteIdentifier(parentColumnName), parentIndex, name)
 			continue
 		}
 		names = append(names, v.Name)
@@ -83,20 +153,20 @@ func (c *CORM) InsertRow(s interface{}) (int64, error) {
 		positionSql = ", " + positionSql
 		names = append(names, positionColumnName)
 	}
-	sqlReq := fmt.Sprintf("INSERT INTO %s(%s) VALUES(%s%s) returning id;", table.Name, strings.Join(names, ", "), placeholders, positionSql)
-
-	err = c.db.QueryRow(sqlReq, values...).Scan(&id)
-	if err != nil {
-		return 0, err
-	}
-	if id == 0 {
-		return 0, errors.New("no new id returned")
-	}
-
-	return id, nil
+	st.sql = fmt.Sprintf("INSERT INTO %s(%s) VALUES(%s%s) returning %s;", table.quotedName(), strings.Join(quoteNames(names), ", "), placeholders, positionSql, strings.Join(quoteNames(append([]string{"id"}, st.returning.names...)), ", "))
+	st.args = values
+	return st, nil
 }
 
 func (c *CORM) DeleteRowById(s interface{}) error {
+	oc, op := c.startTableOperation("DeleteRowById", s)
+	err := oc.deleteRowById(s)
+	c.invalidateReadCacheCascade(op.table)
+	op.finish(err)
+	return err
+}
+
+func (c *CORM) deleteRowById(s interface{}) error {
 	tableName := GetTableName(s)
 	if tableName == "" {
 		return errors.New("no table name")
@@ -111,40 +181,76 @@ func (c *CORM) DeleteRowById(s interface{}) error {
 		return errors.New("no id value")
 	}
 
-	sqlReq := fmt.Sprintf("DELETE FROM %s WHERE id = $1;", tableName)
-	_, err := c.db.Exec(sqlReq, f.Int())
+	err := c.runHook(hookBeforeDelete, s)
+	if err != nil {
+		return err
+	}
+	err = c.deleteRowByArgId(s, f.Int())
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return c.runHook(hookAfterDelete, s)
 }
 
 func (c *CORM) DeleteRowByArgId(s interface{}, id int64) error {
+	oc, op := c.startTableOperation("DeleteRowByArgId", s)
+	err := oc.deleteRowByArgId(s, id)
+	c.invalidateReadCacheCascade(op.table)
+	op.finish(err)
+	return err
+}
+
+func (c *CORM) deleteRowByArgId(s interface{}, id int64) error {
 	if id == 0 {
 		return errors.New("no id value")
 	}
-	tableName := GetTableName(s)
-	if tableName == "" {
-		return errors.New("no table name")
+	table, err := c.writeTable(s)
+	if err != nil {
+		return err
 	}
-	sqlReq := fmt.Sprintf("DELETE FROM %s WHERE id = $1;", tableName)
-	_, err := c.db.Exec(sqlReq, id)
+	where, args := table.tenantWhere(`"id" = $1`, []interface{}{id})
+	res, err := c.exec(table.deleteSql(where), args...)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return table.checkTenantRows(res)
 }
 
 func (c *CORM) DeleteRows(s interface{}, fieldNames map[string]bool) error {
-	table, err := c.GetTable(s)
+	oc, op := c.startTableOperation("DeleteRows", s)
+	err := oc.deleteRows(s, fieldNames)
+	c.invalidateReadCacheCascade(op.table)
+	op.finish(err)
+	return err
+}
+
+func (c *CORM) deleteRows(s interface{}, fieldNames map[string]bool) error {
+	err := c.runHook(hookBeforeDelete, s)
+	if err != nil {
+		return err
+	}
+	table, err := c.writeTable(s)
+	if err != nil {
+		return err
+	}
+	st, err := table.deleteStatement(fieldNames)
+	if err != nil {
+		return err
+	}
+	_, err = c.exec(st.sql, st.args...)
 	if err != nil {
 		return err
 	}
+
+	return c.runHook(hookAfterDelete, s)
+}
+
+// deleteStatement generates statement deleting rows equal to the table row by the field names
+func (table *Table) deleteStatement(fieldNames map[string]bool) (*writeStatement, error) {
 	var names []string
 	var values []interface{}
-	var updatePos bool
 	for _, v := range table.Columns {
 		if !fieldNames[v.FieldName] {
 			continue
@@ -160,45 +266,138 @@ func (c *CORM) DeleteRows(s interface{}, fieldNames map[string]bool) error {
 		values = append(values, v.ColumnValue)
 	}
 	if len(names) == 0 {
-		if updatePos {
-			return nil
+		return nil, errors.New("no fields to delete")
+	}
+	where, values := table.tenantWhere(ValuesEqualPlaceholdersAnd(quoteNames(names)), values)
+	return &writeStatement{sql: table.deleteSql(where), args: values}, nil
+}
+
+func (c *CORM) UpdateRow(s interface{}, onlyFields bool, fieldNames map[string]bool) error {
+	oc, op := c.startTableOperation("UpdateRow", s)
+	err := oc.updateRow(s, onlyFields, fieldNames)
+	c.invalidateReadCache(op.table)
+	op.finish(err)
+	return err
+}
+
+func (c *CORM) updateRow(s interface{}, onlyFields bool, fieldNames map[string]bool) error {
+	err := c.runHook(hookBeforeUpdate, s)
+	if err != nil {
+		return err
+	}
+	table, err := c.writeTable(s)
+	if err != nil {
+		return err
+	}
+	st, err := table.updateStatement(onlyFields, fieldNames)
+	if err != nil {
+		return err
+	}
+	var found bool
+	if st.movePosition && c.tx == nil {
+		// version check and position change are committed together
+		var tx *sql.Tx
+		tx, err = c.db.BeginTx(c.getContext(), nil)
+		if err != nil {
+			return err
 		}
-		return errors.New("no fields to delete")
+		tc := c.WithTx(tx)
+		defer tc.Rollback()
+		found, err = tc.runUpdate(table, st)
+		if err == nil {
+			err = tc.Commit()
+		}
+	} else {
+		found, err = c.runUpdate(table, st)
 	}
-	sqlReq := fmt.Sprintf("DELETE FROM %s WHERE %s;", table.Name, ValuesEqualPlaceholdersAnd(names))
-	_, err = c.db.Exec(sqlReq, values...)
 	if err != nil {
 		return err
 	}
+	if found {
+		st.returning.writeBack(s)
+		table.writeTenant(s)
+	}
 
-	return nil
+	return c.runHook(hookAfterUpdate, s)
 }
 
-func (c *CORM) UpdateRow(s interface{}, onlyFields bool, fieldNames map[string]bool) error {
-	table, err := c.GetTable(s)
-	if err != nil {
-		return err
+// runUpdate runs update statement and then position change, the row version is checked before the position is changed.
+// Missing row is not an error, it is reported as not found and its position is not changed.
+func (c *CORM) runUpdate(table Table, st *writeStatement) (bool, error) {
+	if st.sql != "" && len(st.returning.names) == 0 {
+		res, err := c.exec(st.sql, st.args...)
+		if err != nil {
+			return false, err
+		}
+		err = table.checkTenantRows(res)
+		if err != nil {
+			return false, err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return false, err
+		}
+		if n == 0 {
+			return false, nil
+		}
+	} else if st.sql != "" {
+		err := c.queryRow(st.sql, st.args, st.returning.ptrs()...)
+		if err == sql.ErrNoRows && st.versioned {
+			return false, ErrStaleObject
+		}
+		if err == sql.ErrNoRows && table.tenantScoped {
+			return false, ErrTenantMismatch
+		}
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
 	}
+	if !st.movePosition {
+		return true, nil
+	}
+	err := c.movePosition(table)
+	if err == sql.ErrNoRows && st.sql == "" {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// updateStatement generates statement updating the table row by id, position changes are made by MovePosition
+func (table *Table) updateStatement(onlyFields bool, fieldNames map[string]bool) (*writeStatement, error) {
 	var names []string
 	var values []interface{}
 	var itemId int64
-	var updatePos bool
-	for _, v := range table.Columns {
+	var setLines []string
+	var versionColumn *Column
+	st := &writeStatement{}
+	for i, v := range table.Columns {
 		if v.Name == "id" {
 			itemId = v.Value.(int64)
 			continue
 		}
+		if v.IsCreated || v.IsSoftDelete {
+			continue
+		}
+		if v.IsUpdated {
+			st.returning.addTime(v)
+			setLines = append(setLines, pq.QuoteIdentifier(v.Name)+" = now()")
+			continue
+		}
+		if v.IsVersion {
+			versionColumn = &table.Columns[i]
+			st.returning.add(v, new(int64))
+			name := pq.QuoteIdentifier(v.Name)
+			setLines = append(setLines, name+" = "+name+" + 1")
+			continue
+		}
 		if onlyFields && !fieldNames[v.FieldName] {
 			continue
 		}
 		if v.IsPosition {
-			err = c.MovePosition(table)
-			if err != nil {
-				return err
-			}
-			if len(fieldNames) == 1 {
-				return nil
-			}
+			st.movePosition = true
 			continue
 		}
 		names = append(names, v.Name)
@@ -206,7 +405,7 @@ func (c *CORM) UpdateRow(s interface{}, onlyFields bool, fieldNames map[string]b
 	}
 
 	if itemId == 0 {
-		return errors.New("no row id")
+		return nil, errors.New("no row id")
 	}
 
 	for _, v := range table.FKeys {
@@ -216,116 +415,69 @@ func (c *CORM) UpdateRow(s interface{}, onlyFields bool, fieldNames map[string]b
 		names = append(names, v.ColumnName)
 		values = append(values, v.ColumnValue)
 	}
-	if len(names) == 0 {
-		if updatePos {
-			return nil
-		}
-		return errors.New("no fields to update")
+	if len(names) == 0 && !st.movePosition {
+		return nil, errors.New("no fields to update")
 	}
-	sqlReq := fmt.Sprintf("UPDATE %s SET %s WHERE id = %d;", table.Name, ValuesEqualPlaceholders(names), itemId)
-	_, err = c.db.Exec(sqlReq, values...)
-	if err != nil {
-		return err
+	if len(names) == 0 && len(setLines) == 0 {
+		// position is updated by MovePosition statements only
+		return st, nil
 	}
-
-	return nil
+	if len(names) > 0 {
+		setLines = append([]string{ValuesEqualPlaceholders(quoteNames(names))}, setLines...)
+	}
+	setLine := strings.Join(setLines, ", ")
+	values = append(values, itemId)
+	where := fmt.Sprintf(`"id" = $%d`, len(values))
+	if versionColumn != nil {
+		values = append(values, versionColumn.Value)
+		where += fmt.Sprintf(" AND %s = $%d", pq.QuoteIdentifier(versionColumn.Name), len(values))
+		st.versioned = true
+	}
+	where, values = table.tenantWhere(where, values)
+	st.args = values
+	if len(st.returning.names) == 0 {
+		st.sql = fmt.Sprintf("UPDATE %s SET %s WHERE %s;", table.quotedName(), setLine, where)
+		return st, nil
+	}
+	st.sql = fmt.Sprintf("UPDATE %s SET %s WHERE %s RETURNING %s;", table.quotedName(), setLine, where, strings.Join(quoteNames(st.returning.names), ", "))
+	return st, nil
 }
 
 // rarely used
 func (c *CORM) GetDataAll(s interface{}, asMap bool) (interface{}, error) {
+	oc, op := c.startTableOperation("GetDataAll", s)
+	res, err := oc.getDataAll(s, asMap)
+	op.finish(err)
+	return res, err
+}
+
+func (c *CORM) getDataAll(s interface{}, asMap bool) (interface{}, error) {
 	table, err := c.GetTable(s)
 	if err != nil {
 		return nil, err
 	}
-	var maxLimit = 100000
-	var names []string
-	indirect := reflect.ValueOf(table.Instance)
-
-	for _, v := range table.Columns {
-		names = append(names, v.Name)
-	}
-	for i := 0; i < indirect.NumField(); i++ {
-		if indirect.Field(i).Kind() != reflect.Ptr {
-			continue
-		}
-		for _, v := range table.FKeys {
-			if indirect.Type().Field(i).Type.Elem() != v.Type {
-				continue
-			}
-			name := v.ColumnName
-			if v.IsNull {
-				//TODO: for now int only
-				name = fmt.Sprintf("COALESCE(%s, 0)", v.ColumnName)
-			}
-			names = append(names, name)
-		}
-	}
-
-	sqlReq := fmt.Sprintf(`SELECT %s FROM %s LIMIT %d;`, strings.Join(names, ", "), table.Name, maxLimit)
-	results, err := c.db.Query(sqlReq)
+	q, err := c.newSelectQuery(table, Filters{}, true)
 	if err != nil {
-		log.Printf("%+v", err)
 		return nil, err
 	}
-	defer results.Close()
-	var res []interface{}
-	var resMap = make(map[int64]interface{})
+	capLimit := c.limitRows(q, 0)
 
-	for results.Next() {
-		var ptrs []interface{}
-		var idPtr reflect.Value
-		newIndirect := reflect.New(indirect.Type()).Elem()
-		for i := 0; i < newIndirect.NumField(); i++ {
-			f := newIndirect.Field(i)
-			t := newIndirect.Type().Field(i)
-			if asMap && t.Name == "Id" && t.Type.Kind() == reflect.Int64 {
-				idPtr = f.Addr()
-			}
-			tag, ok := t.Tag.Lookup("customsql")
-			if !ok || tag == "" {
-				continue
-			}
-			if f.Kind() == reflect.Ptr {
-				for _, v := range table.FKeys {
-					if t.Name != v.FieldName {
-						continue
-					}
-					newValPkey := reflect.New(v.Type)
-					f2 := newValPkey.Elem().FieldByName("Id")
-					ptrs = append(ptrs, f2.Addr().Interface())
-					f.Set(newValPkey)
-				}
-				continue
-			}
-			ptrs = append(ptrs, f.Addr().Interface())
-		}
-
-		err = results.Scan(ptrs...)
-		if err != nil {
-			log.Printf("%+v", err)
-			return nil, err
-		}
-		if asMap {
-			resMap[idPtr.Elem().Int()] = newIndirect.Interface()
-		} else {
-			res = append(res, newIndirect.Interface())
-		}
-	}
-	if asMap {
-		delete(resMap, 0)
-		return resMap, nil
-	}
-	return res, nil
+	return c.readRows(q, asMap, capLimit)
 }
 
 func (c *CORM) GetDataById(s interface{}, id int64) (interface{}, error) {
+	oc, op := c.startTableOperation("GetDataById", s)
+	res, err := oc.getDataById(s, id)
+	op.finish(err)
+	return res, err
+}
+
+func (c *CORM) getDataById(s interface{}, id int64) (interface{}, error) {
 	table, err := c.GetTable(s)
 	if err != nil {
 		return nil, err
 	}
-	var names []string
 	var itemId interface{}
-	var ptrs []interface{}
 
 	for _, v := range table.Columns {
 		if v.Name == "id" {
@@ -335,52 +487,50 @@ func (c *CORM) GetDataById(s interface{}, id int64) (interface{}, error) {
 	if id != 0 {
 		itemId = id
 	}
-	indirect := reflect.ValueOf(table.Instance)
-	newIndirect := reflect.New(indirect.Type()).Elem()
+	q, err := c.newSelectQuery(table, Filters{}, true)
+	if err != nil {
+		return nil, err
+	}
+	q.wheres = append([]string{q.column("id") + " = " + q.arg(itemId)}, q.wheres...)
+	sqlReq := q.selectSql() + ";"
 
-	for i := 0; i < newIndirect.NumField(); i++ {
-		f := newIndirect.Field(i)
-		t := newIndirect.Type().Field(i)
-		tag, ok := t.Tag.Lookup("customsql")
-		if !ok || tag == "" {
-			continue
-		}
-		if f.Kind() == reflect.Ptr {
-			for _, v := range table.FKeys {
-				if t.Name != v.FieldName {
-					continue
-				}
-				newValPkey := reflect.New(v.Type)
-				f2 := newValPkey.Elem().FieldByName("Id")
-				ptrs = append(ptrs, f2.Addr().Interface())
-				name := v.ColumnName
-				if v.IsNull {
-					//TODO: for now int only
-					name = fmt.Sprintf("COALESCE(%s, 0)", v.ColumnName)
-				}
-				names = append(names, name)
-				f.Set(newValPkey)
-			}
-			continue
-		}
-		for _, v := range table.Columns {
-			if v.FieldName != t.Name {
-				continue
-			}
-			names = append(names, v.Name)
+	rc := c.cachedRead(table.Name)
+	cacheKey := ""
+	if rc != nil {
+		cacheKey = c.readCacheKey(sqlReq, q.args, false)
+		if res, ok := rc.backend.Get(table.Name, cacheKey); ok {
+			return res, nil
 		}
-		ptrs = append(ptrs, f.Addr().Interface())
 	}
 
-	sqlReq := fmt.Sprintf(`SELECT %s FROM %s WHERE id = %d;`, strings.Join(names, ", "), table.Name, itemId)
-	row := c.db.QueryRow(sqlReq)
-
-	err = row.Scan(ptrs...)
+	results, st, err := c.query(sqlReq, q.args...)
 	if err != nil {
-		log.Printf("%+v", err)
 		return nil, err
 	}
-
+	defer results.Close()
+	var rowsCount int64
+	defer func() { st.finish(rowsCount, err) }()
+	if !results.Next() {
+		err = results.Err()
+		if err == nil {
+			err = sql.ErrNoRows
+		}
+		return nil, err
+	}
+	newIndirect, err := newRowScanner(table, q.fnames).scan(results)
+	if err != nil {
+		return nil, err
+	}
+	rowsCount++
+	// rows are closed before hooks, so they can run statements on the connection
+	results.Close()
+	err = c.runHook(hookAfterFind, newIndirect.Addr().Interface())
+	if err != nil {
+		return nil, err
+	}
+	if rc != nil {
+		rc.backend.Set(table.Name, cacheKey, newIndirect.Interface())
+	}
 	return newIndirect.Interface(), nil
 }
 
@@ -424,196 +574,149 @@ func getFilterParams(filter Filters, fieldName, name string, defaultValue interf
 }
 
 func (c *CORM) GetDataByValue(s interface{}, filter Filters, asMap bool) (interface{}, error) {
-	if len(filter.Fields) == 0 {
-		return nil, errors.New("no values")
-	}
-	table, err := c.GetTable(s)
+	oc, op := c.startTableOperation("GetDataByValue", s)
+	res, err := oc.getDataByValue(s, filter, asMap)
+	op.finish(err)
+	return res, err
+}
+
+func (c *CORM) getDataByValue(s interface{}, filter Filters, asMap bool) (interface{}, error) {
+	q, capLimit, err := c.getDataByValueQuery(s, filter, asMap)
 	if err != nil {
 		return nil, err
 	}
-	var maxLimit = 100000
-	var names []string
-	var fnames []string
-	var wheres []string
-	var wheresArgs []interface{}
-	var offset string
-	var order string
-	var positionColumnName string
-	var primaryKeyColumnName string
-	indirect := reflect.ValueOf(table.Instance)
+	table := q.table
 
-	p := 1
-	for _, v := range table.Columns {
-		names = append(names, v.Name)
-		fnames = append(fnames, v.FieldName)
-		if v.IsPosition {
-			positionColumnName = v.Name
+	if filter.Count {
+		sqlReq := q.countSql()
+		rc := c.cachedQuery(q)
+		cacheKey := ""
+		if rc != nil {
+			cacheKey = c.readCacheKey(sqlReq, q.args, asMap)
+			if res, ok := rc.backend.Get(table.Name, cacheKey); ok {
+				return copyResult(res), nil
+			}
 		}
-		fName, val, operand, postOperand := getFilterParams(filter, v.FieldName, v.Name, v.Value)
-		if fName == "" {
-			continue
+		var count int64
+		err = c.queryRow(sqlReq, q.args, &count)
+		if err != nil {
+			return nil, err
 		}
-
-		line := v.Name + " " + operand + " $" + strconv.Itoa(p) + postOperand
-
-		wheres = append(wheres, line)
-		wheresArgs = append(wheresArgs, val)
-		p++
+		if rc != nil {
+			rc.backend.Set(table.Name, cacheKey, []interface{}{count})
+		}
+		return []interface{}{count}, nil
 	}
 
-	for i := 0; i < indirect.NumField(); i++ {
-		if indirect.Field(i).Kind() != reflect.Ptr {
-			continue
-		}
-		t := indirect.Type().Field(i)
-		for _, v := range table.FKeys {
-			if t.Name != v.FieldName {
-				continue
-			}
-			name := v.ColumnName
-			if v.IsNull {
-				//TODO: for now int only
-				name = fmt.Sprintf("COALESCE(%s, 0)", v.ColumnName)
-			}
-			names = append(names, name)
-			fnames = append(fnames, v.FieldName)
-			//TODO: for one key only for now
-			primaryKeyColumnName = name
-			/*if isNil(v.ColumnValue) || v.ColumnValue == "" || v.ColumnValue == 0 || v.ColumnValue == false {
-				continue
-			}*/
-			fName, val, operand, postOperand := getFilterParams(filter, v.FieldName, name, v.ColumnValue)
-			if fName == "" {
-				continue
-			}
-			line := v.ColumnName + " " + operand + " $" + strconv.Itoa(p) + postOperand
+	return c.readRows(q, asMap, capLimit)
+}
 
-			wheres = append(wheres, line)
-			wheresArgs = append(wheresArgs, val)
-			p++
-		}
+// getDataByValueQuery generates select query of GetDataByValue and returns it with applied rows cap
+func (c *CORM) getDataByValueQuery(s interface{}, filter Filters, asMap bool) (*selectQuery, int, error) {
+	if len(filter.Fields) == 0 && len(filter.Subqueries) == 0 {
+		return nil, 0, errors.New("no values")
 	}
-
-	if len(wheres) == 0 && filter.Limit == 0 {
-		return nil, errors.New("no search values")
+	table, err := c.GetTable(s)
+	if err != nil {
+		return nil, 0, err
+	}
+	q, err := c.newSelectQuery(table, filter, asMap)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !q.filtered && filter.Limit == 0 {
+		return nil, 0, errors.New("no search values")
 	}
 
-	if len(filter.Order.Fields) > 0 {
-		desc := "ASC"
-		if filter.Order.Desc {
-			desc = "DESC"
-		}
+	capLimit := c.limitRows(q, filter.Limit)
+	return q, capLimit, nil
+}
 
-		order = fmt.Sprintf("ORDER BY %s %s ", strings.Join(filter.Order.Fields, ", "), desc)
-	} else if !asMap && (positionColumnName != "" || primaryKeyColumnName != "") {
-		var args []string
-		if primaryKeyColumnName != "" {
-			args = append(args, primaryKeyColumnName)
-		}
-		if positionColumnName != "" {
-			args = append(args, positionColumnName)
+// readRows runs select query and returns rows as slice or map by id, using read cache when enabled.
+// Reaching capLimit rows is reported to metrics, or returns ErrResultTruncated in strict mode.
+func (c *CORM) readRows(q *selectQuery, asMap bool, capLimit int) (interface{}, error) {
+	table := q.table
+	sqlReq := q.selectSql() + ";"
+	rc := c.cachedQuery(q)
+	cacheKey := ""
+	if rc != nil {
+		cacheKey = c.readCacheKey(sqlReq, q.args, asMap)
+		if res, ok := rc.backend.Get(table.Name, cacheKey); ok {
+			return copyResult(res), nil
 		}
-
-		//TODO: now its just automatic way
-		order = fmt.Sprintf("ORDER BY %s ", strings.Join(args, ", "))
 	}
 
-	if filter.Limit != 0 && filter.Limit < maxLimit {
-		maxLimit = filter.Limit
-	}
-	if filter.Offset != 0 {
-		offset = fmt.Sprintf("OFFSET %d", filter.Offset)
-	}
-	where := "WHERE "
-	if len(wheres) == 0 {
-		where = ""
+	results, st, err := c.query(sqlReq, q.args...)
+	if err != nil {
+		return nil, err
 	}
-	sqlReq := fmt.Sprintf(
-		`SELECT %s FROM %s %s %s LIMIT %d %s;`,
-		strings.Join(names, ", "),
-		table.Name,
-		where+strings.Join(wheres, " AND "),
-		order,
-		maxLimit,
-		offset,
-	)
-	if filter.Count {
-		sqlReq = fmt.Sprintf(
-			`SELECT %s FROM %s %s;`,
-			"COUNT(*)",
-			table.Name,
-			where+strings.Join(wheres, " AND "),
-		)
-		var count int64
-		err = c.db.QueryRow(sqlReq, wheresArgs...).Scan(&count)
+	defer results.Close()
+	var rowsCount int64
+	defer func() { st.finish(rowsCount, err) }()
+	var rows []reflect.Value
+	var scanner = newRowScanner(table, q.fnames)
+	for results.Next() {
+		if capLimit > 0 && rowsCount == int64(capLimit) {
+			// row after the cap is fetched in strict mode only
+			c.markCapReached()
+			err = ErrResultTruncated
+			return nil, err
+		}
+		var newIndirect reflect.Value
+		newIndirect, err = scanner.scan(results)
 		if err != nil {
-			log.Println(sqlReq)
-			log.Println(wheresArgs)
-			log.Printf("%+v", err)
 			return nil, err
 		}
-		return []interface{}{count}, nil
+		rowsCount++
+		rows = append(rows, newIndirect)
 	}
-
-	results, err := c.db.Query(sqlReq, wheresArgs...)
-	if err != nil {
-		log.Println(sqlReq)
-		log.Println(wheresArgs)
-		log.Printf("%+v", err)
+	if err = results.Err(); err != nil {
 		return nil, err
 	}
-	defer results.Close()
+	if capLimit > 0 && rowsCount >= int64(capLimit) {
+		c.markCapReached()
+	}
+	// rows are closed before hooks, so they can run statements on the connection
+	results.Close()
 	var res []interface{}
 	var resMap = make(map[int64]interface{})
-	var indexes = IndexesMap(fnames)
-	for results.Next() {
-		var ptrs = make([]interface{}, len(names))
-		var idPtr reflect.Value
-		newIndirect := reflect.New(indirect.Type()).Elem()
-		for i := 0; i < newIndirect.NumField(); i++ {
-			f := newIndirect.Field(i)
-			t := newIndirect.Type().Field(i)
-			if asMap && t.Name == "Id" && t.Type.Kind() == reflect.Int64 {
-				idPtr = f.Addr()
-			}
-			tag, ok := t.Tag.Lookup("customsql")
-			if !ok || tag == "" {
-				continue
-			}
-
-			if f.Kind() == reflect.Ptr {
-				for _, v := range table.FKeys {
-					if t.Name != v.FieldName {
-						continue
-					}
-					newValPkey := reflect.New(v.Type)
-					f2 := newValPkey.Elem().FieldByName("Id")
-					ptrs[indexes[v.FieldName]] = f2.Addr().Interface()
-					f.Set(newValPkey)
-				}
-				continue
-			}
-			ptrs[indexes[t.Name]] = f.Addr().Interface()
-		}
-		err = results.Scan(ptrs...)
+	for _, newIndirect := range rows {
+		err = c.runHook(hookAfterFind, newIndirect.Addr().Interface())
 		if err != nil {
-			log.Printf("%+v", err)
 			return nil, err
 		}
 		if asMap {
-			resMap[idPtr.Elem().Int()] = newIndirect.Interface()
+			resMap[scanner.mapKey(newIndirect)] = newIndirect.Interface()
 		} else {
 			res = append(res, newIndirect.Interface())
 		}
 	}
 	if asMap {
 		delete(resMap, 0)
+		if rc != nil {
+			rc.backend.Set(table.Name, cacheKey, copyResult(resMap))
+		}
 		return resMap, nil
 	}
+	if rc != nil {
+		rc.backend.Set(table.Name, cacheKey, copyResult(res))
+	}
 	return res, nil
 }
 
 func (c *CORM) MovePosition(table Table) error {
+	oc, op := c.startOperation("MovePosition", table.Name)
+	err := oc.movePosition(table)
+	c.invalidateReadCache(op.table)
+	op.finish(err)
+	return err
+}
+
+func (c *CORM) movePosition(table Table) error {
+	err := c.scopeTenant(&table)
+	if err != nil {
+		return err
+	}
 	var newPosition int64
 	var id int64
 	var positionColumnName string
@@ -646,15 +749,31 @@ func (c *CORM) MovePosition(table Table) error {
 		return errors.New("no parent column name")
 	}
 
-	tr, err := c.db.Begin()
-	if err != nil {
-		return err
+	tableName := table.quotedName()
+	positionColumnName = pq.QuoteIdentifier(positionColumnName)
+	parentColumnName = pq.QuoteIdentifier(parentColumnName)
+
+	// reuse active transaction or run own one
+	var ownTx *sql.Tx
+	tr := c
+	if c.tx == nil {
+		ownTx, err = c.db.BeginTx(c.getContext(), nil)
+		if err != nil {
+			return err
+		}
+		tr = c.WithTx(ownTx)
+		defer tr.Rollback()
 	}
-	defer tr.Rollback()
 
+	// row of other tenant is not found
+	where, args := table.tenantWhere(`"id" = $1`, []interface{}{id})
 	var oldPosition int64
-	if parentColumnValue == 0 {
-		err = tr.QueryRow(fmt.Sprintf(`SELECT %s, %s FROM %s WHERE id = $1`, positionColumnName, parentColumnName, table.Name), id).Scan(&oldPosition, &parentColumnValue)
+	if parentColumnValue == 0 || table.tenantScoped {
+		// parent of tenant scoped row is read from the row itself
+		err = tr.queryRow(fmt.Sprintf(`SELECT %s, %s FROM %s WHERE %s`, positionColumnName, parentColumnName, tableName, where), args, &oldPosition, &parentColumnValue)
+		if err == sql.ErrNoRows && table.tenantScoped {
+			return ErrTenantMismatch
+		}
 		if err != nil {
 			return err
 		}
@@ -664,7 +783,7 @@ func (c *CORM) MovePosition(table Table) error {
 	}
 
 	if oldPosition == 0 {
-		err = tr.QueryRow(fmt.Sprintf(`SELECT %s FROM %s WHERE id = $1`, positionColumnName, table.Name), id).Scan(&oldPosition)
+		err = tr.queryRow(fmt.Sprintf(`SELECT %s FROM %s WHERE %s`, positionColumnName, tableName, where), args, &oldPosition)
 		if err != nil {
 			return err
 		}
@@ -680,33 +799,36 @@ func (c *CORM) MovePosition(table Table) error {
 		pos2 = newPosition
 	}
 
-	_, err = tr.Exec(fmt.Sprintf(`UPDATE %s SET %s = (%s + 1)*-1 WHERE %s = $1 AND %s > $2`, table.Name, positionColumnName, positionColumnName, parentColumnName, positionColumnName),
-		parentColumnValue, pos1)
+	where, args = table.tenantWhere(fmt.Sprintf(`%s = $1 AND %s > $2`, parentColumnName, positionColumnName), []interface{}{parentColumnValue, pos1})
+	_, err = tr.exec(fmt.Sprintf(`UPDATE %s SET %s = (%s + 1)*-1 WHERE %s`, tableName, positionColumnName, positionColumnName, where), args...)
 	if err != nil {
 		return err
 	}
-	_, err = tr.Exec(fmt.Sprintf(`UPDATE %s SET %s = (%s)*-1 WHERE %s < 0`, table.Name, positionColumnName, positionColumnName, positionColumnName))
+	negativeWhere, negativeArgs := table.tenantWhere(fmt.Sprintf(`%s < 0`, positionColumnName), nil)
+	_, err = tr.exec(fmt.Sprintf(`UPDATE %s SET %s = (%s)*-1 WHERE %s`, tableName, positionColumnName, positionColumnName, negativeWhere), negativeArgs...)
 	if err != nil {
 		return err
 	}
-	_, err = tr.Exec(fmt.Sprintf(`UPDATE %s SET %s = $2 WHERE id = $1`, table.Name, positionColumnName),
-		id, pos2)
+	where, args = table.tenantWhere(`"id" = $1`, []interface{}{id, pos2})
+	_, err = tr.exec(fmt.Sprintf(`UPDATE %s SET %s = $2 WHERE %s`, tableName, positionColumnName, where), args...)
 	if err != nil {
 		return err
 	}
-	_, err = tr.Exec(fmt.Sprintf(`UPDATE %s SET %s = (%s - 1)*-1 WHERE %s = $1 AND %s > $2`, table.Name, positionColumnName, positionColumnName, parentColumnName, positionColumnName),
-		parentColumnValue, oldPosition)
+	where, args = table.tenantWhere(fmt.Sprintf(`%s = $1 AND %s > $2`, parentColumnName, positionColumnName), []interface{}{parentColumnValue, oldPosition})
+	_, err = tr.exec(fmt.Sprintf(`UPDATE %s SET %s = (%s - 1)*-1 WHERE %s`, tableName, positionColumnName, positionColumnName, where), args...)
 	if err != nil {
 		return err
 	}
-	_, err = tr.Exec(fmt.Sprintf(`UPDATE %s SET %s = (%s)*-1 WHERE %s < 0`, table.Name, positionColumnName, positionColumnName, positionColumnName))
+	_, err = tr.exec(fmt.Sprintf(`UPDATE %s SET %s = (%s)*-1 WHERE %s`, tableName, positionColumnName, positionColumnName, negativeWhere), negativeArgs...)
 	if err != nil {
 		return err
 	}
 
-	err = tr.Commit()
-	if err != nil {
-		return err
+	if ownTx != nil {
+		err = tr.Commit()
+		if err != nil {
+			return err
+		}
 	}
 
 	return err
@@ -0,0 +1,23 @@
+package customorm
+
+import (
+	"database/sql/driver"
+	"testing"
+)
+
+func TestUpdateRowMissingRow(t *testing.T) {
+	db := openFakeDBWith(&fakeDB{rowsAffected: 0, rows: func(string) ([]string, [][]driver.Value) { return []string{"id"}, nil }})
+	defer db.Close()
+	c := Init(db)
+	domain := &testDomain{Id: 1, Name: "example"}
+	if err := c.UpdateRow(domain, true, map[string]bool{"Name": true}); err != nil {
+		t.Errorf("update without returning: got error %v", err)
+	}
+	user := &testDomainUser{Id: 1, Name: "user", Parent: &testDomain{Id: 1}}
+	if err := c.UpdateRow(user, true, map[string]bool{"Name": true}); err != ErrStaleObject {
+		t.Errorf("versioned update: got error %v, want ErrStaleObject", err)
+	}
+	if user.Version != 0 {
+		t.Errorf("version %d is written back for missing row", user.Version)
+	}
+}
@@ -0,0 +1,189 @@
+package customorm
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Error classes of operations failed without SQLSTATE code
+const (
+	ErrorClassStale = "stale"
+	ErrorClassOther = "other"
+)
+
+// LatencyBuckets are upper bounds in seconds of operation latency histogram, same as Prometheus default buckets
+var LatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// OperationStats describes finished call of CORM method
+type OperationStats struct {
+	Operation  string
+	Table      string
+	Duration   time.Duration
+	Rows       int64
+	Err        error
+	ErrorClass string
+	CapReached bool
+}
+
+// MetricsCollector interface receiving every finished CORM operation, e.g. adapter to Prometheus collectors
+type MetricsCollector interface {
+	ObserveOperation(stats OperationStats)
+}
+
+// Histogram with cumulative bucket counts in Prometheus format
+type Histogram struct {
+	Buckets []float64
+	Counts  []uint64
+	Sum     float64
+	Count   uint64
+}
+
+// OperationMetrics holds counters of CORM method calls for one table
+type OperationMetrics struct {
+	Operation string
+	Table     string
+	Queries   int64
+	Errors    map[string]int64
+	Rows      int64
+	CapHits   int64
+	Latency   Histogram
+}
+
+// MetricsSnapshot is a copy of collected metrics ordered by table and operation
+type MetricsSnapshot struct {
+	Operations []OperationMetrics
+}
+
+type metricsKey struct {
+	operation string
+	table     string
+}
+
+// metricsRegistry collects operation metrics inside CORM
+type metricsRegistry struct {
+	mu         sync.Mutex
+	operations map[metricsKey]*OperationMetrics
+}
+
+func newMetricsRegistry() *metricsRegistry {
+	return &metricsRegistry{operations: map[metricsKey]*OperationMetrics{}}
+}
+
+// ObserveOperation updates counters of the operation
+func (r *metricsRegistry) ObserveOperation(stats OperationStats) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := metricsKey{operation: stats.Operation, table: stats.Table}
+	m, ok := r.operations[key]
+	if !ok {
+		m = &OperationMetrics{
+			Operation: stats.Operation,
+			Table:     stats.Table,
+			Errors:    map[string]int64{},
+			Latency:   Histogram{Buckets: LatencyBuckets, Counts: make([]uint64, len(LatencyBuckets))},
+		}
+		r.operations[key] = m
+	}
+	m.Queries++
+	m.Rows += stats.Rows
+	if stats.ErrorClass != "" {
+		m.Errors[stats.ErrorClass]++
+	}
+	if stats.CapReached {
+		m.CapHits++
+	}
+	seconds := stats.Duration.Seconds()
+	for i, bound := range m.Latency.Buckets {
+		if seconds <= bound {
+			m.Latency.Counts[i]++
+		}
+	}
+	m.Latency.Sum += seconds
+	m.Latency.Count++
+}
+
+// snapshot returns copy of collected metrics
+func (r *metricsRegistry) snapshot() MetricsSnapshot {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var res MetricsSnapshot
+	for _, m := range r.operations {
+		cp := *m
+		cp.Errors = make(map[string]int64, len(m.Errors))
+		for k, v := range m.Errors {
+			cp.Errors[k] = v
+		}
+		cp.Latency.Counts = make([]uint64, len(m.Latency.Counts))
+		copy(cp.Latency.Counts, m.Latency.Counts)
+		res.Operations = append(res.Operations, cp)
+	}
+	sort.Slice(res.Operations, func(i, j int) bool {
+		if res.Operations[i].Table != res.Operations[j].Table {
+			return res.Operations[i].Table < res.Operations[j].Table
+		}
+		return res.Operations[i].Operation < res.Operations[j].Operation
+	})
+	return res
+}
+
+// errorClass returns SQLSTATE class of the error or own class for non database errors
+func errorClass(err error) string {
+	if err == nil {
+		return ""
+	}
+	if errors.Is(err, ErrStaleObject) {
+		return ErrorClassStale
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return string(pqErr.Code.Class())
+	}
+	return ErrorClassOther
+}
+
+// Metrics returns snapshot of metrics collected by CORM
+func (c *CORM) Metrics() MetricsSnapshot {
+	if c.metrics == nil {
+		return MetricsSnapshot{}
+	}
+	return c.metrics.snapshot()
+}
+
+// SetMetricsCollector sets collector receiving every finished operation in addition to CORM own metrics
+func (c *CORM) SetMetricsCollector(collector MetricsCollector) *CORM {
+	c.metricsCollector = collector
+	return c
+}
+
+// observeOperation reports finished operation to metrics
+func (c *CORM) observeOperation(o *operation, err error) {
+	if c.metrics == nil && c.metricsCollector == nil {
+		return
+	}
+	stats := OperationStats{
+		Operation:  o.name,
+		Table:      o.table,
+		Duration:   time.Since(o.start),
+		Rows:       o.rows,
+		Err:        err,
+		ErrorClass: errorClass(err),
+		CapReached: o.capReached,
+	}
+	if c.metrics != nil {
+		c.metrics.ObserveOperation(stats)
+	}
+	if c.metricsCollector != nil {
+		c.metricsCollector.ObserveOperation(stats)
+	}
+}
+
+// markCapReached marks current operation as truncated by the maximum rows limit
+func (c *CORM) markCapReached() {
+	if c.op != nil {
+		c.op.capReached = true
+	}
+}
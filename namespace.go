@@ -0,0 +1,72 @@
+package customorm
+
+import (
+	"errors"
+	"reflect"
+
+	"github.com/lib/pq"
+)
+
+// SchemaRow interface to get PostgreSQL schema name of the table
+type SchemaRow interface {
+	GetSchemaName() string
+}
+
+// SetSchema sets default schema of tables not implementing SchemaRow, empty name uses search_path of the connection
+func (c *CORM) SetSchema(name string) *CORM {
+	c.schema = name
+	return c
+}
+
+// GetSchemaName retrieves the schema name from the provided instance, empty if it does not implement SchemaRow
+func GetSchemaName(i interface{}) string {
+	if row, ok := i.(SchemaRow); ok {
+		return row.GetSchemaName()
+	}
+	if i == nil || reflect.ValueOf(i).Kind() == reflect.Ptr {
+		return ""
+	}
+	ptr := reflect.New(reflect.TypeOf(i))
+	ptr.Elem().Set(reflect.ValueOf(i))
+	if row, ok := ptr.Interface().(SchemaRow); ok {
+		return row.GetSchemaName()
+	}
+	return ""
+}
+
+// resolveSchemaName returns schema name of the table struct falling back to the default schema
+func (c *CORM) resolveSchemaName(name string) (string, error) {
+	if name == "" {
+		name = c.schema
+	}
+	if name != "" && !isValidTableName(name) {
+		return "", errors.New("schema name have wrong format: " + name)
+	}
+	return name, nil
+}
+
+// qualifiedName returns quoted table name prefixed by quoted schema name if set
+func qualifiedName(schema, name string) string {
+	if schema == "" {
+		return pq.QuoteIdentifier(name)
+	}
+	return pq.QuoteIdentifier(schema) + "." + pq.QuoteIdentifier(name)
+}
+
+// createSchema creates schema of the table if it does not exist yet
+func (c *CORM) createSchema(table Table) error {
+	sqlReq := table.createSchemaSql()
+	if sqlReq == "" {
+		return nil
+	}
+	_, err := c.exec(sqlReq)
+	return err
+}
+
+// createSchemaSql generates statement creating schema of the table if it does not exist yet
+func (table *Table) createSchemaSql() string {
+	if table.Schema == "" {
+		return ""
+	}
+	return "CREATE SCHEMA IF NOT EXISTS " + pq.QuoteIdentifier(table.Schema) + ";"
+}
@@ -0,0 +1,395 @@
+package customorm
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// defaultMaxLimit is default maximum number of rows returned by readers materializing results
+const defaultMaxLimit = 100000
+
+// selectQuery holds SELECT statement generated from table struct and filters
+type selectQuery struct {
+	table Table
+	alias string
+	joins []selectJoin
+	// query reads other tables by joins or subqueries
+	related  bool
+	names    []string
+	fnames   []string
+	distinct string
+	wheres   []string
+	args     []interface{}
+	order    string
+	limit    int
+	offset   int
+	filtered bool
+}
+
+// arg adds statement argument and returns its placeholder
+func (q *selectQuery) arg(value interface{}) string {
+	q.args = append(q.args, value)
+	return "$" + strconv.Itoa(len(q.args))
+}
+
+// newSelectQuery generates selected columns, conditions and order of the table filtered by values
+func (c *CORM) newSelectQuery(table Table, filter Filters, asMap bool) (*selectQuery, error) {
+	if filter.Error != nil {
+		return nil, filter.Error
+	}
+	q := &selectQuery{table: table, limit: filter.Limit, offset: filter.Offset}
+	err := c.buildSelectQuery(q, filter, asMap)
+	if err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// buildSelectQuery fills selected columns, conditions and order of the query table filtered by values
+func (c *CORM) buildSelectQuery(q *selectQuery, filter Filters, asMap bool) error {
+	table := q.table
+	var positionColumnName string
+	var primaryKeyColumnName string
+	indirect := reflect.ValueOf(table.Instance)
+	selected, err := selectedFields(table, filter.Select, asMap)
+	if err != nil {
+		return err
+	}
+	if len(filter.Subqueries) > 0 && q.alias == "" {
+		q.alias = mainTableAlias
+	}
+	err = c.addJoins(q, filter)
+	if err != nil {
+		return err
+	}
+	err = q.validateFilterFields(filter)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range table.Columns {
+		if selected == nil || selected[v.FieldName] {
+			q.names = append(q.names, q.column(v.Name))
+			q.fnames = append(q.fnames, v.FieldName)
+		}
+		if v.IsPosition {
+			positionColumnName = q.column(v.Name)
+		}
+		fName, val, operand, postOperand := getFilterParams(filter, v.FieldName, v.Name, v.Value)
+		if fName == "" {
+			continue
+		}
+		q.wheres = append(q.wheres, q.column(v.Name)+" "+operand+" "+q.arg(val)+postOperand)
+	}
+
+	for i := 0; i < indirect.NumField(); i++ {
+		if indirect.Field(i).Kind() != reflect.Ptr {
+			continue
+		}
+		t := indirect.Type().Field(i)
+		for _, v := range table.FKeys {
+			if t.Name != v.FieldName {
+				continue
+			}
+			name := q.column(v.ColumnName)
+			if v.IsNull {
+				//TODO: for now int only
+				name = fmt.Sprintf("COALESCE(%s, 0)", q.column(v.ColumnName))
+			}
+			if selected == nil || selected[v.FieldName] {
+				q.names = append(q.names, name)
+				q.fnames = append(q.fnames, v.FieldName)
+			}
+			//TODO: for one key only for now
+			primaryKeyColumnName = name
+			fName, val, operand, postOperand := getFilterParams(filter, v.FieldName, v.ColumnName, v.ColumnValue)
+			if fName == "" {
+				continue
+			}
+			q.wheres = append(q.wheres, q.column(v.ColumnName)+" "+operand+" "+q.arg(val)+postOperand)
+		}
+	}
+	q.addJoinFilters(filter)
+	err = c.addSubqueries(q, filter)
+	if err != nil {
+		return err
+	}
+	q.filtered = len(q.wheres) > 0
+
+	if condition := c.deletedCondition(table, q.alias); condition != "" {
+		q.wheres = append(q.wheres, condition)
+	}
+	err = c.addTenantCondition(q)
+	if err != nil {
+		return err
+	}
+
+	distinctOn, err := q.distinctColumns(filter)
+	if err != nil {
+		return err
+	}
+	if filter.Distinct {
+		q.distinct = "DISTINCT "
+	} else if len(distinctOn) > 0 {
+		q.distinct = fmt.Sprintf("DISTINCT ON (%s) ", strings.Join(distinctOn, ", "))
+	}
+
+	if len(distinctOn) > 0 && len(filter.Order.Fields) == 0 {
+		q.order = fmt.Sprintf("ORDER BY %s", strings.Join(distinctOn, ", "))
+	} else if len(filter.Order.Fields) > 0 {
+		desc := "ASC"
+		if filter.Order.Desc {
+			desc = "DESC"
+		}
+
+		fields, err := q.orderColumns(filter.Order.Fields)
+		if err != nil {
+			return err
+		}
+		if filter.Distinct {
+			err = q.checkSelectedOrder(filter.Order.Fields, fields)
+			if err != nil {
+				return err
+			}
+		}
+		q.order = fmt.Sprintf("ORDER BY %s %s", strings.Join(fields, ", "), desc)
+	} else if !asMap && q.distinct == "" && (positionColumnName != "" || primaryKeyColumnName != "") {
+		// automatic order is skipped for DISTINCT as its columns may be not selected
+		var args []string
+		if primaryKeyColumnName != "" {
+			args = append(args, primaryKeyColumnName)
+		}
+		if positionColumnName != "" {
+			args = append(args, positionColumnName)
+		}
+
+		//TODO: now its just automatic way
+		q.order = fmt.Sprintf("ORDER BY %s", strings.Join(args, ", "))
+	}
+	return nil
+}
+
+// validateFilterFields checks that used filter fields are fields or columns of the query tables
+func (q *selectQuery) validateFilterFields(filter Filters) error {
+	for name, field := range filter.Fields {
+		if !field.Flag {
+			continue
+		}
+		if _, ok := q.fieldColumn(name); !ok {
+			return fmt.Errorf("unknown filter field %s of table %s", name, q.table.Name)
+		}
+	}
+	return nil
+}
+
+// orderColumns returns qualified column names of the order fields
+func (q *selectQuery) orderColumns(fields []string) ([]string, error) {
+	var names []string
+	for _, field := range fields {
+		name, ok := q.fieldColumn(field)
+		if !ok {
+			return nil, fmt.Errorf("unknown order field %s of table %s", field, q.table.Name)
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// checkSelectedOrder checks that order columns of DISTINCT query are selected
+func (q *selectQuery) checkSelectedOrder(fields, columns []string) error {
+	var selected = map[string]bool{}
+	for _, name := range q.names {
+		selected[name] = true
+	}
+	for i, name := range columns {
+		if !selected[name] {
+			return fmt.Errorf("order field %s must be selected in DISTINCT query", fields[i])
+		}
+	}
+	return nil
+}
+
+// selectedFields returns set of selected field names or nil when all fields are selected,
+// Id is always selected for results mapped by id
+func selectedFields(table Table, fields []string, asMap bool) (map[string]bool, error) {
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	var known = map[string]bool{}
+	for _, v := range table.Columns {
+		known[v.FieldName] = true
+	}
+	for _, v := range table.FKeys {
+		known[v.FieldName] = true
+	}
+	var selected = map[string]bool{}
+	for _, name := range fields {
+		if !known[name] {
+			return nil, fmt.Errorf("unknown select field %s of table %s", name, table.Name)
+		}
+		selected[name] = true
+	}
+	if asMap && known["Id"] {
+		selected["Id"] = true
+	}
+	return selected, nil
+}
+
+// distinctColumns returns column names of DISTINCT ON fields checking they lead the order
+func (q *selectQuery) distinctColumns(filter Filters) ([]string, error) {
+	if len(filter.DistinctOn) == 0 {
+		return nil, nil
+	}
+	if filter.Distinct {
+		return nil, errors.New("filter can not use both Distinct and DistinctOn")
+	}
+	var names []string
+	var leading = map[string]bool{}
+	for _, field := range filter.DistinctOn {
+		name, ok := q.fieldColumn(field)
+		if !ok {
+			return nil, fmt.Errorf("unknown distinct field %s of table %s", field, q.table.Name)
+		}
+		names = append(names, name)
+		leading[name] = true
+	}
+	if len(filter.Order.Fields) == 0 {
+		return names, nil
+	}
+	if len(filter.Order.Fields) < len(names) {
+		return nil, errors.New("fields of DistinctOn must lead the order fields")
+	}
+	orderNames, err := q.orderColumns(filter.Order.Fields[:len(names)])
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range orderNames {
+		if !leading[name] {
+			return nil, errors.New("fields of DistinctOn must lead the order fields")
+		}
+		delete(leading, name)
+	}
+	return names, nil
+}
+
+// where returns WHERE clause of the query
+func (q *selectQuery) where() string {
+	if len(q.wheres) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(q.wheres, " AND ")
+}
+
+// selectSql returns SELECT statement without trailing semicolon
+func (q *selectQuery) selectSql() string {
+	sqlReq := fmt.Sprintf(`SELECT %s%s FROM %s%s`, q.distinct, strings.Join(q.names, ", "), q.from(), q.where())
+	if q.order != "" {
+		sqlReq += " " + q.order
+	}
+	if q.limit > 0 {
+		sqlReq += fmt.Sprintf(" LIMIT %d", q.limit)
+	}
+	if q.offset > 0 {
+		sqlReq += fmt.Sprintf(" OFFSET %d", q.offset)
+	}
+	return sqlReq
+}
+
+// countSql returns statement counting rows matched by the query
+func (q *selectQuery) countSql() string {
+	if q.distinct != "" {
+		// distinct rows are counted by the select without order and limits
+		sub := *q
+		sub.order, sub.limit, sub.offset = "", 0, 0
+		return fmt.Sprintf(`SELECT COUNT(*) FROM (%s) AS distinct_rows;`, sub.selectSql())
+	}
+	return fmt.Sprintf(`SELECT COUNT(*) FROM %s%s;`, q.from(), q.where())
+}
+
+// scanTarget describes struct field receiving selected column
+type scanTarget struct {
+	index []int
+	fKey  *FKey
+	// nullable foreign key leaves the field nil for NULL column
+	nullable bool
+}
+
+// rowScanner creates structs of the table type from selected rows
+type rowScanner struct {
+	typ     reflect.Type
+	targets []scanTarget
+	idIndex []int
+}
+
+// newRowScanner prepares scan targets of the selected fields
+func newRowScanner(table Table, fnames []string) *rowScanner {
+	typ := reflect.TypeOf(table.Instance)
+	rs := &rowScanner{typ: typ}
+	if f, ok := typ.FieldByName("Id"); ok && f.Type.Kind() == reflect.Int64 {
+		rs.idIndex = f.Index
+	}
+	for _, name := range fnames {
+		f, _ := typ.FieldByName(name)
+		target := scanTarget{index: f.Index}
+		for i := range table.FKeys {
+			if table.FKeys[i].FieldName == name {
+				target.fKey = &table.FKeys[i]
+			}
+		}
+		rs.targets = append(rs.targets, target)
+	}
+	return rs
+}
+
+// scan reads current row into new struct value
+func (rs *rowScanner) scan(results *sql.Rows) (reflect.Value, error) {
+	newIndirect := reflect.New(rs.typ).Elem()
+	var ptrs = make([]interface{}, len(rs.targets))
+	var nullKeys map[int]*sql.NullInt64
+	for i, target := range rs.targets {
+		f := newIndirect.FieldByIndex(target.index)
+		if target.nullable {
+			if nullKeys == nil {
+				nullKeys = map[int]*sql.NullInt64{}
+			}
+			nullKeys[i] = &sql.NullInt64{}
+			ptrs[i] = nullKeys[i]
+			continue
+		}
+		if target.fKey != nil {
+			newValPkey := reflect.New(target.fKey.Type)
+			f2 := newValPkey.Elem().FieldByName("Id")
+			ptrs[i] = f2.Addr().Interface()
+			f.Set(newValPkey)
+			continue
+		}
+		ptrs[i] = f.Addr().Interface()
+	}
+	err := results.Scan(ptrs...)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	for i, key := range nullKeys {
+		if !key.Valid {
+			continue
+		}
+		target := rs.targets[i]
+		newValPkey := reflect.New(target.fKey.Type)
+		newValPkey.Elem().FieldByName("Id").SetInt(key.Int64)
+		newIndirect.FieldByIndex(target.index).Set(newValPkey)
+	}
+	normalizeTimes(newIndirect)
+	return newIndirect, nil
+}
+
+// mapKey returns id of the scanned struct used as a key of map results
+func (rs *rowScanner) mapKey(v reflect.Value) int64 {
+	if rs.idIndex == nil {
+		return 0
+	}
+	return v.FieldByIndex(rs.idIndex).Int()
+}
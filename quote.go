@@ -0,0 +1,25 @@
+package customorm
+
+import "github.com/lib/pq"
+
+// quotedName returns quoted table name qualified by schema used in generated SQL
+func (table *Table) quotedName() string {
+	return qualifiedName(table.Schema, table.Name)
+}
+
+// sqlType returns column type used in generated SQL, enum type names are quoted and qualified by the table schema
+func (c *Column) sqlType() string {
+	if len(c.EnumValues) == 0 {
+		return c.Type
+	}
+	return qualifiedName(c.enumSchema, c.Type)
+}
+
+// quoteNames returns quoted identifiers
+func quoteNames(names []string) []string {
+	res := make([]string, len(names))
+	for i, name := range names {
+		res[i] = pq.QuoteIdentifier(name)
+	}
+	return res
+}
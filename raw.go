@@ -0,0 +1,178 @@
+package customorm
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// RawQuery runs the statement and scans result rows into dest, which is a pointer to table struct,
+// to slice of table structs or to slice of pointers to them. Result columns are mapped to fields by customsql
+// column names, foreign key columns like "parent_id" fill Id of the referenced struct.
+// Columns without field and fields without column are reported as error.
+// Single struct dest returns sql.ErrNoRows when there are no rows.
+func (c *CORM) RawQuery(dest interface{}, query string, args ...interface{}) error {
+	return c.runRawQuery("RawQuery", dest, query, args, false)
+}
+
+// RawQueryPartial is RawQuery selecting only some of the struct columns, fields without column stay zero-valued
+func (c *CORM) RawQueryPartial(dest interface{}, query string, args ...interface{}) error {
+	return c.runRawQuery("RawQueryPartial", dest, query, args, true)
+}
+
+func (c *CORM) runRawQuery(name string, dest interface{}, query string, args []interface{}, partial bool) error {
+	v := reflect.ValueOf(dest)
+	var tableName string
+	if v.Kind() == reflect.Ptr && !v.IsNil() {
+		if typ := rawRowType(v.Elem().Type()); typ != nil {
+			tableName = GetTableName(reflect.New(typ).Interface())
+		}
+	}
+	oc, op := c.startOperation(name, tableName)
+	err := oc.rawQuery(v, query, args, partial)
+	op.finish(err)
+	return err
+}
+
+// rawRowType returns struct type of the raw query row of dest type or nil for unsupported dest
+func rawRowType(typ reflect.Type) reflect.Type {
+	if typ.Kind() == reflect.Struct {
+		return typ
+	}
+	if typ.Kind() != reflect.Slice {
+		return nil
+	}
+	typ = typ.Elem()
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return nil
+	}
+	return typ
+}
+
+func (c *CORM) rawQuery(dest reflect.Value, query string, args []interface{}, partial bool) (err error) {
+	if dest.Kind() != reflect.Ptr || dest.IsNil() {
+		return errors.New("dest must be a non-nil pointer")
+	}
+	typ := rawRowType(dest.Elem().Type())
+	if typ == nil {
+		return errors.New("dest must be a pointer to struct or to slice of structs")
+	}
+	table, err := c.GetTable(reflect.New(typ).Interface())
+	if err != nil {
+		return err
+	}
+
+	results, st, err := c.query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer results.Close()
+	var rowsCount int64
+	defer func() { st.finish(rowsCount, err) }()
+	columns, err := results.Columns()
+	if err != nil {
+		return err
+	}
+	scanner, err := newRawScanner(table, columns, partial)
+	if err != nil {
+		return err
+	}
+
+	single := dest.Elem().Kind() == reflect.Struct
+	var rows []reflect.Value
+	for results.Next() {
+		var row reflect.Value
+		row, err = scanner.scan(results)
+		if err != nil {
+			return err
+		}
+		rowsCount++
+		rows = append(rows, row)
+		if single {
+			break
+		}
+	}
+	if err = results.Err(); err != nil {
+		return err
+	}
+	if single && rowsCount == 0 {
+		err = sql.ErrNoRows
+		return err
+	}
+	// rows are closed before hooks, so they can run statements on the connection
+	results.Close()
+	for _, row := range rows {
+		err = c.runHook(hookAfterFind, row.Addr().Interface())
+		if err != nil {
+			return err
+		}
+		if single {
+			dest.Elem().Set(row)
+			break
+		}
+		list := dest.Elem()
+		if list.Type().Elem().Kind() == reflect.Ptr {
+			list.Set(reflect.Append(list, row.Addr()))
+		} else {
+			list.Set(reflect.Append(list, row))
+		}
+	}
+	return err
+}
+
+// newRawScanner prepares scan targets of result columns mapped by column names of the table,
+// columns of the table missing in the result are reported unless the scan is partial
+func newRawScanner(table Table, columns []string, partial bool) (*rowScanner, error) {
+	var fields = map[string]string{}
+	for _, v := range table.Columns {
+		fields[v.Name] = v.FieldName
+	}
+	for _, v := range table.FKeys {
+		fields[v.ColumnName] = v.FieldName
+	}
+	var fnames []string
+	var unmapped []string
+	var seen = map[string]bool{}
+	for _, column := range columns {
+		fieldName, ok := fields[column]
+		if !ok {
+			unmapped = append(unmapped, column)
+			continue
+		}
+		if seen[column] {
+			return nil, fmt.Errorf("duplicate column %s in result", column)
+		}
+		seen[column] = true
+		fnames = append(fnames, fieldName)
+	}
+	if len(unmapped) > 0 {
+		return nil, fmt.Errorf("no fields of %s for columns %s", reflect.TypeOf(table.Instance), strings.Join(unmapped, ", "))
+	}
+	if !partial {
+		var missing []string
+		for _, v := range table.Columns {
+			if !seen[v.Name] {
+				missing = append(missing, v.Name)
+			}
+		}
+		for _, v := range table.FKeys {
+			if !seen[v.ColumnName] {
+				missing = append(missing, v.ColumnName)
+			}
+		}
+		if len(missing) > 0 {
+			return nil, fmt.Errorf("no columns %s of %s in result", strings.Join(missing, ", "), reflect.TypeOf(table.Instance))
+		}
+	}
+	rs := newRowScanner(table, fnames)
+	for i := range rs.targets {
+		// foreign keys are not coalesced in raw statements
+		rs.targets[i].nullable = rs.targets[i].fKey != nil
+	}
+	return rs, nil
+}
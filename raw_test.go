@@ -0,0 +1,63 @@
+package customorm
+
+import (
+	"database/sql/driver"
+	"strings"
+	"testing"
+)
+
+func TestNewRawScanner(t *testing.T) {
+	table, err := Init(nil).GetTable(&testDomainUser{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	all := []string{"id", "position", "enabled", "name", "updated_at", "deleted_at", "version", "parent_id"}
+	tests := []struct {
+		name    string
+		columns []string
+		partial bool
+		err     string
+	}{
+		{name: "all columns", columns: all},
+		{name: "reordered columns", columns: append([]string{"parent_id"}, all[:7]...)},
+		{name: "unmapped column", columns: append(append([]string(nil), all...), "extra"), err: "for columns extra"},
+		{name: "missing column", columns: all[1:], err: "no columns id of"},
+		{name: "partial missing column", columns: []string{"id", "parent_id"}, partial: true},
+		{name: "partial unmapped column", columns: []string{"id", "extra"}, partial: true, err: "for columns extra"},
+		{name: "duplicate column", columns: append(append([]string(nil), all...), "name"), err: "duplicate column name"},
+	}
+	for _, tt := range tests {
+		scanner, err := newRawScanner(table, tt.columns, tt.partial)
+		if tt.err == "" {
+			if err != nil {
+				t.Errorf("%s: %v", tt.name, err)
+			} else if len(scanner.targets) != len(tt.columns) {
+				t.Errorf("%s: got %d targets, want %d", tt.name, len(scanner.targets), len(tt.columns))
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: got error %v, want %s", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestRawQueryNullableForeignKey(t *testing.T) {
+	db := openFakeDBWith(&fakeDB{rows: func(string) ([]string, [][]driver.Value) {
+		return []string{"id", "name", "parent_id"}, [][]driver.Value{{int64(1), "orphan", nil}, {int64(2), "child", int64(5)}}
+	}})
+	defer db.Close()
+	var users []*testDomainUser
+	if err := Init(db).RawQueryPartial(&users, `SELECT id, name, parent_id FROM domain_users`); err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 2 {
+		t.Fatalf("got %d rows, want 2", len(users))
+	}
+	if users[0].Parent != nil {
+		t.Errorf("NULL foreign key: got parent %+v", users[0].Parent)
+	}
+	if users[1].Parent == nil || users[1].Parent.Id != 5 || users[1].Name != "child" {
+		t.Errorf("foreign key: got %+v", users[1])
+	}
+}
@@ -0,0 +1,317 @@
+package customorm
+
+import (
+	"container/list"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache interface of second-level read cache backend
+type Cache interface {
+	Get(table, key string) (interface{}, bool)
+	Set(table, key string, value interface{})
+	InvalidateTable(table string)
+}
+
+// readCache holds cache backend and tables using it
+type readCache struct {
+	backend Cache
+	// table names mapped to names of tables they reference by foreign keys
+	tables map[string][]string
+	mu     sync.Mutex
+	// tables written by unfinished transactions mapped to number of the transactions
+	pending map[string]int
+}
+
+// SetReadCache enables read cache of GetDataAll, GetDataById and GetDataByValue for the given table structs, nil cache disables it
+func (c *CORM) SetReadCache(cache Cache, tables ...interface{}) *CORM {
+	if cache == nil {
+		c.readCache = nil
+		return c
+	}
+	rc := &readCache{backend: cache, tables: map[string][]string{}, pending: map[string]int{}}
+	for _, s := range tables {
+		table, err := c.GetTable(s)
+		if err != nil {
+			continue
+		}
+		var parents []string
+		for _, v := range table.FKeys {
+			parents = append(parents, v.TableName)
+		}
+		rc.tables[table.Name] = parents
+	}
+	c.readCache = rc
+	return c
+}
+
+// cachedRead returns read cache of the table or nil if reads of the table are not cached
+func (c *CORM) cachedRead(table string) *readCache {
+	// reads inside transaction can see uncommitted rows
+	if c.readCache == nil || c.tx != nil {
+		return nil
+	}
+	if _, ok := c.readCache.tables[table]; !ok {
+		return nil
+	}
+	// rows written by unfinished transaction could be cached before its commit
+	if c.readCache.isPending(table) {
+		return nil
+	}
+	return c.readCache
+}
+
+// cachedQuery returns read cache of the select query or nil, results depending on other tables are not cached
+func (c *CORM) cachedQuery(q *selectQuery) *readCache {
+	if q.related {
+		return nil
+	}
+	return c.cachedRead(q.table.Name)
+}
+
+// readCacheKey returns cache key of the read statement. Arguments are encoded by their driver values
+// with Go syntax, so values of different arguments can not run together and pointers are not printed as addresses.
+func (c *CORM) readCacheKey(query string, args []interface{}, asMap bool) string {
+	var key strings.Builder
+	fmt.Fprintf(&key, "%t|%d|%#v|%d:%s|%d", asMap, c.deletedScope, c.tenant, len(query), query, len(args))
+	for _, arg := range args {
+		if valuer, ok := arg.(driver.Valuer); ok && !isNil(arg) {
+			if value, err := valuer.Value(); err == nil {
+				arg = value
+			}
+		}
+		fmt.Fprintf(&key, "|%#v", arg)
+	}
+	return key.String()
+}
+
+// InvalidateReadCache removes cached reads of the table structs and of tables referencing them,
+// e.g. after writes made outside of CORM. Called on WithTx copy after its transaction is finished on sql.Tx,
+// it also invalidates tables written in the transaction and allows caching them again.
+func (c *CORM) InvalidateReadCache(tables ...interface{}) {
+	if c.readCache == nil {
+		return
+	}
+	if c.txWrites != nil {
+		c.txWrites.finish()
+	}
+	for _, s := range tables {
+		c.invalidateTableCascade(GetTableName(s))
+	}
+}
+
+// invalidateReadCache removes cached reads of the table
+func (c *CORM) invalidateReadCache(table string) {
+	if c.readCache == nil {
+		return
+	}
+	c.invalidateTables([]string{table})
+}
+
+// invalidateReadCacheCascade removes cached reads of the table and of tables referencing it, used after deletes
+func (c *CORM) invalidateReadCacheCascade(table string) {
+	if c.readCache == nil {
+		return
+	}
+	c.invalidateTableCascade(table)
+}
+
+// invalidateTableCascade removes cached reads of the table and of tables referencing it
+func (c *CORM) invalidateTableCascade(table string) {
+	c.invalidateTables(c.readCache.cascadeTables(table))
+}
+
+// invalidateTables removes cached reads of the tables, tables written inside transaction
+// are not cached until it is finished and are invalidated again then
+func (c *CORM) invalidateTables(tables []string) {
+	if c.tx != nil && c.txWrites != nil {
+		c.txWrites.add(c.readCache, tables)
+	}
+	for _, name := range tables {
+		c.readCache.backend.InvalidateTable(name)
+	}
+}
+
+// cascadeTables returns the table and tables referencing it directly or through other tables
+func (rc *readCache) cascadeTables(table string) []string {
+	var res []string
+	var seen = map[string]bool{}
+	var queue = []string{table}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		res = append(res, name)
+		for child, parents := range rc.tables {
+			for _, parent := range parents {
+				if parent == name {
+					queue = append(queue, child)
+				}
+			}
+		}
+	}
+	return res
+}
+
+// setPending changes number of unfinished transactions writing the tables
+func (rc *readCache) setPending(tables []string, delta int) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	for _, name := range tables {
+		rc.pending[name] += delta
+		if rc.pending[name] <= 0 {
+			delete(rc.pending, name)
+		}
+	}
+}
+
+// isPending checks if the table is written by unfinished transaction
+func (rc *readCache) isPending(table string) bool {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return rc.pending[table] > 0
+}
+
+// txWrites records tables written inside transaction of WithTx copy
+type txWrites struct {
+	mu     sync.Mutex
+	cache  *readCache
+	tables []string
+	seen   map[string]bool
+	done   bool
+}
+
+// add records written tables marking them pending in the read cache
+func (w *txWrites) add(rc *readCache, tables []string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.done || (w.cache != nil && w.cache != rc) {
+		return
+	}
+	w.cache = rc
+	var added []string
+	for _, name := range tables {
+		if !w.seen[name] {
+			w.seen[name] = true
+			added = append(added, name)
+		}
+	}
+	w.tables = append(w.tables, added...)
+	rc.setPending(added, 1)
+}
+
+// finish invalidates cached reads of written tables once the transaction is finished
+func (w *txWrites) finish() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.done {
+		return
+	}
+	w.done = true
+	if w.cache == nil {
+		return
+	}
+	for _, name := range w.tables {
+		w.cache.backend.InvalidateTable(name)
+	}
+	w.cache.setPending(w.tables, -1)
+}
+
+// copyResult returns shallow copy of cached result so callers can not modify the cache
+func copyResult(res interface{}) interface{} {
+	switch v := res.(type) {
+	case []interface{}:
+		return append([]interface{}(nil), v...)
+	case map[int64]interface{}:
+		m := make(map[int64]interface{}, len(v))
+		for k, val := range v {
+			m[k] = val
+		}
+		return m
+	}
+	return res
+}
+
+// LRUCache is an in-memory Cache with size limit and entries expiring after TTL
+type LRUCache struct {
+	mu    sync.Mutex
+	size  int
+	ttl   time.Duration
+	items map[lruKey]*list.Element
+	lru   *list.List
+}
+
+type lruKey struct {
+	table string
+	key   string
+}
+
+type lruItem struct {
+	key     lruKey
+	value   interface{}
+	expires time.Time
+}
+
+// NewLRUCache returns in-memory cache holding up to size entries, zero ttl keeps entries until eviction
+func NewLRUCache(size int, ttl time.Duration) *LRUCache {
+	return &LRUCache{size: size, ttl: ttl, items: map[lruKey]*list.Element{}, lru: list.New()}
+}
+
+// Get returns not expired cached value
+func (l *LRUCache) Get(table, key string) (interface{}, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	el, ok := l.items[lruKey{table: table, key: key}]
+	if !ok {
+		return nil, false
+	}
+	item := el.Value.(*lruItem)
+	if !item.expires.IsZero() && time.Now().After(item.expires) {
+		l.remove(el)
+		return nil, false
+	}
+	l.lru.MoveToFront(el)
+	return item.value, true
+}
+
+// Set stores value evicting least recently used entries
+func (l *LRUCache) Set(table, key string, value interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	k := lruKey{table: table, key: key}
+	item := &lruItem{key: k, value: value}
+	if l.ttl > 0 {
+		item.expires = time.Now().Add(l.ttl)
+	}
+	if el, ok := l.items[k]; ok {
+		el.Value = item
+		l.lru.MoveToFront(el)
+		return
+	}
+	l.items[k] = l.lru.PushFront(item)
+	for l.size > 0 && l.lru.Len() > l.size {
+		l.remove(l.lru.Back())
+	}
+}
+
+// InvalidateTable removes all entries of the table
+func (l *LRUCache) InvalidateTable(table string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for k, el := range l.items {
+		if k.table == table {
+			l.remove(el)
+		}
+	}
+}
+
+func (l *LRUCache) remove(el *list.Element) {
+	l.lru.Remove(el)
+	delete(l.items, el.Value.(*lruItem).key)
+}
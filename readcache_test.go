@@ -0,0 +1,169 @@
+package customorm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+func TestReadCacheKey(t *testing.T) {
+	c := Init(nil)
+	query := `SELECT "id" FROM "domains" WHERE "name" = $1 AND "enabled" = $2`
+	distinct := [][2]string{
+		{
+			c.readCacheKey(query, []interface{}{"a b", "c"}, false),
+			c.readCacheKey(query, []interface{}{"a", "b c"}, false),
+		},
+		{
+			c.readCacheKey(query, []interface{}{int64(1)}, false),
+			c.readCacheKey(query, []interface{}{"1"}, false),
+		},
+		{
+			c.readCacheKey(query, []interface{}{"a"}, false),
+			c.readCacheKey(query, []interface{}{"a"}, true),
+		},
+		{
+			c.readCacheKey(query, []interface{}{"a"}, false),
+			c.WithDeleted().readCacheKey(query, []interface{}{"a"}, false),
+		},
+		{
+			c.ForTenant(int64(1)).readCacheKey(query, nil, false),
+			c.ForTenant("1").readCacheKey(query, nil, false),
+		},
+		{
+			c.readCacheKey(query, []interface{}{pq.Array([]int64{1, 2})}, false),
+			c.readCacheKey(query, []interface{}{pq.Array([]int64{1, 3})}, false),
+		},
+	}
+	for i, keys := range distinct {
+		if keys[0] == keys[1] {
+			t.Errorf("%d: different reads share key %s", i, keys[0])
+		}
+	}
+	first := c.readCacheKey(query, []interface{}{pq.Array([]int64{1, 2}), time.Unix(0, 0).UTC()}, false)
+	second := c.readCacheKey(query, []interface{}{pq.Array([]int64{1, 2}), time.Unix(0, 0).UTC()}, false)
+	if first != second {
+		t.Errorf("equal reads have different keys %s and %s", first, second)
+	}
+}
+
+func TestLRUCacheEviction(t *testing.T) {
+	l := NewLRUCache(2, 0)
+	l.Set("a", "1", 1)
+	l.Set("a", "2", 2)
+	if _, ok := l.Get("a", "1"); !ok {
+		t.Fatal("entry is missing")
+	}
+	// least recently used entry "2" is evicted
+	l.Set("b", "1", 3)
+	if _, ok := l.Get("a", "2"); ok {
+		t.Error("least recently used entry is not evicted")
+	}
+	if v, ok := l.Get("a", "1"); !ok || v != 1 {
+		t.Errorf("got %v, %t", v, ok)
+	}
+	l.InvalidateTable("a")
+	if _, ok := l.Get("a", "1"); ok {
+		t.Error("entry of invalidated table is returned")
+	}
+	if v, ok := l.Get("b", "1"); !ok || v != 3 {
+		t.Errorf("entry of other table: got %v, %t", v, ok)
+	}
+}
+
+func TestLRUCacheTTL(t *testing.T) {
+	l := NewLRUCache(10, 20*time.Millisecond)
+	l.Set("a", "1", 1)
+	if _, ok := l.Get("a", "1"); !ok {
+		t.Fatal("entry is missing before expiration")
+	}
+	time.Sleep(30 * time.Millisecond)
+	if _, ok := l.Get("a", "1"); ok {
+		t.Error("expired entry is returned")
+	}
+	if l.lru.Len() != 0 {
+		t.Errorf("expired entry is kept, %d entries", l.lru.Len())
+	}
+}
+
+// recordingCache records invalidated tables
+type recordingCache struct {
+	invalidated []string
+}
+
+func (r *recordingCache) Get(string, string) (interface{}, bool) { return nil, false }
+func (r *recordingCache) Set(string, string, interface{})        {}
+func (r *recordingCache) InvalidateTable(table string) {
+	r.invalidated = append(r.invalidated, table)
+}
+
+func TestInvalidateTableCascade(t *testing.T) {
+	cache := &recordingCache{}
+	c := Init(nil).SetReadCache(cache, &testDomain{}, &testDomainUser{})
+	c.invalidateTableCascade("domain_users")
+	if len(cache.invalidated) != 1 || cache.invalidated[0] != "domain_users" {
+		t.Errorf("child invalidation: got %v", cache.invalidated)
+	}
+	cache.invalidated = nil
+	c.invalidateTableCascade("domains")
+	if len(cache.invalidated) != 2 || cache.invalidated[0] != "domains" || cache.invalidated[1] != "domain_users" {
+		t.Errorf("parent invalidation: got %v", cache.invalidated)
+	}
+}
+
+func TestReadCacheTransactionWrites(t *testing.T) {
+	db := openFakeDB()
+	defer db.Close()
+	cache := &recordingCache{}
+	c := Init(db).SetReadCache(cache, &testDomain{}, &testDomainUser{})
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tc := c.WithTx(tx)
+	if err := tc.DeleteRowById(&testDomain{Id: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if len(cache.invalidated) != 2 {
+		t.Errorf("write time invalidation: got %v", cache.invalidated)
+	}
+	if c.cachedRead("domains") != nil || c.cachedRead("domain_users") != nil {
+		t.Error("tables written by unfinished transaction are cached")
+	}
+	cache.invalidated = nil
+	if err := tc.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if len(cache.invalidated) != 2 || cache.invalidated[0] != "domains" || cache.invalidated[1] != "domain_users" {
+		t.Errorf("commit invalidation: got %v", cache.invalidated)
+	}
+	if c.cachedRead("domains") == nil {
+		t.Error("tables of finished transaction are not cached")
+	}
+}
+
+func TestReadCacheTransactionFinishedOnTx(t *testing.T) {
+	db := openFakeDB()
+	defer db.Close()
+	cache := &recordingCache{}
+	c := Init(db).SetReadCache(cache, &testDomain{})
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tc := c.WithTx(tx)
+	if err := tc.DeleteRowById(&testDomain{Id: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if c.cachedRead("domains") != nil {
+		t.Error("table is cached before invalidation")
+	}
+	tc.InvalidateReadCache()
+	if c.cachedRead("domains") == nil {
+		t.Error("table is not cached after invalidation")
+	}
+}
@@ -0,0 +1,57 @@
+package customorm
+
+import (
+	"reflect"
+	"time"
+)
+
+// returningColumns holds columns assigned by database on write and scanned back into the struct
+type returningColumns struct {
+	names  []string
+	fields []string
+	values []interface{}
+}
+
+// add registers column to be returned by the statement with the value scanned into dest pointer
+func (r *returningColumns) add(column Column, dest interface{}) {
+	r.names = append(r.names, column.Name)
+	r.fields = append(r.fields, column.FieldName)
+	r.values = append(r.values, dest)
+}
+
+// addTime registers timestamp column to be returned by the statement
+func (r *returningColumns) addTime(column Column) {
+	r.add(column, new(time.Time))
+}
+
+// ptrs returns scan destinations for registered columns
+func (r *returningColumns) ptrs() []interface{} {
+	return r.values
+}
+
+// writeBack sets returned values into fields of the struct if it was passed by pointer
+func (r *returningColumns) writeBack(s interface{}) {
+	for i, fieldName := range r.fields {
+		setFieldValue(s, fieldName, utcValue(reflect.ValueOf(r.values[i]).Elem().Interface()))
+	}
+}
+
+// setFieldValue sets value of the named field if s is a pointer to struct
+func setFieldValue(s interface{}, fieldName string, value interface{}) {
+	ptr := reflect.ValueOf(s)
+	if ptr.Kind() != reflect.Ptr || ptr.IsNil() || ptr.Elem().Kind() != reflect.Struct {
+		return
+	}
+	f := ptr.Elem().FieldByName(fieldName)
+	if !f.IsValid() || !f.CanSet() {
+		return
+	}
+	v := reflect.ValueOf(value)
+	if !v.Type().AssignableTo(f.Type()) {
+		if !v.Type().ConvertibleTo(f.Type()) {
+			return
+		}
+		v = v.Convert(f.Type())
+	}
+	f.Set(v)
+}
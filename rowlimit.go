@@ -0,0 +1,47 @@
+package customorm
+
+// NoRowLimit disables maximum rows limit of GetDataAll and GetDataByValue
+const NoRowLimit = -1
+
+// SetRowLimit sets maximum number of rows returned by GetDataAll and GetDataByValue, 0 restores the default
+// of 100000 rows and NoRowLimit disables it. In strict mode readers return ErrResultTruncated when more rows exist.
+func (c *CORM) SetRowLimit(maxRows int, strict bool) *CORM {
+	c.maxRows = maxRows
+	c.strictRowLimit = strict
+	return c
+}
+
+// WithRowLimit returns CORM copy with maximum rows limit of its readers, see SetRowLimit
+func (c *CORM) WithRowLimit(maxRows int, strict bool) *CORM {
+	n := *c
+	n.maxRows = maxRows
+	n.strictRowLimit = strict
+	return &n
+}
+
+// rowCap returns maximum number of rows returned by materializing readers, 0 when rows are not limited
+func (c *CORM) rowCap() int {
+	switch {
+	case c.maxRows < 0:
+		return 0
+	case c.maxRows == 0:
+		return defaultMaxLimit
+	}
+	return c.maxRows
+}
+
+// limitRows sets LIMIT of the query requested by caller or capped by maximum rows,
+// returns the applied cap or 0 when result is not capped
+func (c *CORM) limitRows(q *selectQuery, limit int) int {
+	maxRows := c.rowCap()
+	if limit != 0 && (maxRows == 0 || limit <= maxRows) {
+		q.limit = limit
+		return 0
+	}
+	q.limit = maxRows
+	if maxRows != 0 && c.strictRowLimit {
+		// one more row tells whether result is truncated
+		q.limit++
+	}
+	return maxRows
+}
@@ -0,0 +1,67 @@
+package customorm
+
+import (
+	"database/sql/driver"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var limitRegex = regexp.MustCompile(` LIMIT (\d+)`)
+
+// openDomainsDB returns fake database holding count rows of domains, LIMIT of queries is applied
+func openDomainsDB(count int) *fakeDB {
+	return &fakeDB{rows: func(query string) ([]string, [][]driver.Value) {
+		n := count
+		if m := limitRegex.FindStringSubmatch(query); m != nil {
+			if limit, _ := strconv.Atoi(m[1]); limit < n {
+				n = limit
+			}
+		}
+		var rows [][]driver.Value
+		for i := 1; i <= n; i++ {
+			rows = append(rows, []driver.Value{int64(i), true, "domain" + strconv.Itoa(i)})
+		}
+		return []string{"id", "enabled", "name"}, rows
+	}}
+}
+
+func TestRowLimit(t *testing.T) {
+	tests := []struct {
+		name    string
+		rows    int
+		maxRows int
+		strict  bool
+		limit   int
+		query   string
+		count   int
+		err     error
+	}{
+		{name: "strict truncated", rows: 3, maxRows: 2, strict: true, query: " LIMIT 3;", err: ErrResultTruncated},
+		{name: "strict at cap", rows: 2, maxRows: 2, strict: true, query: " LIMIT 3;", count: 2},
+		{name: "capped", rows: 3, maxRows: 2, query: " LIMIT 2;", count: 2},
+		{name: "caller limit", rows: 3, maxRows: 2, strict: true, limit: 1, query: " LIMIT 1;", count: 1},
+		{name: "caller limit over cap", rows: 3, maxRows: 2, limit: 5, query: " LIMIT 2;", count: 2},
+		{name: "default", rows: 3, query: " LIMIT 100000;", count: 3},
+		{name: "no limit", rows: 3, maxRows: NoRowLimit, strict: true, query: `WHERE "enabled" = $1;`, count: 3},
+	}
+	for _, tt := range tests {
+		fake := openDomainsDB(tt.rows)
+		db := openFakeDBWith(fake)
+		c := Init(db).WithRowLimit(tt.maxRows, tt.strict)
+		filter := Filters{Fields: map[string]FilterFields{"Enabled": {Flag: true}}, Limit: tt.limit}
+		res, err := c.GetDataByValue(&testDomain{Enabled: true}, filter, false)
+		db.Close()
+		if err != tt.err {
+			t.Errorf("%s: got error %v, want %v", tt.name, err, tt.err)
+			continue
+		}
+		if statements := fake.statements(); len(statements) != 1 || !strings.HasSuffix(statements[0], tt.query) {
+			t.Errorf("%s: got statements %v, want suffix %s", tt.name, statements, tt.query)
+		}
+		if err == nil && len(res.([]interface{})) != tt.count {
+			t.Errorf("%s: got %d rows, want %d", tt.name, len(res.([]interface{})), tt.count)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package customorm
+
+import (
+	"reflect"
+	"sync"
+)
+
+// tableSchemas caches parsed table metadata per struct type
+var tableSchemas sync.Map
+
+// tableSchema holds static table metadata without instance values
+type tableSchema struct {
+	table Table
+	// schemaName is schema returned by the struct, empty uses default schema
+	schemaName string
+	// fKeySchemaNames are schemas returned by structs referenced by foreign keys
+	fKeySchemaNames []string
+}
+
+// getTableSchema returns cached table metadata of the struct type, parsing it on first use
+func getTableSchema(t reflect.Type, tableName string) *tableSchema {
+	if cached, ok := tableSchemas.Load(t); ok {
+		return cached.(*tableSchema)
+	}
+	instance := reflect.Zero(t).Interface()
+	schema := &tableSchema{table: Table{Name: tableName, Instance: instance}, schemaName: GetSchemaName(instance)}
+	schema.table.ImportTableData()
+	for _, fKey := range schema.table.FKeys {
+		schema.fKeySchemaNames = append(schema.fKeySchemaNames, GetSchemaName(reflect.New(fKey.Type).Interface()))
+	}
+	cached, _ := tableSchemas.LoadOrStore(t, schema)
+	return cached.(*tableSchema)
+}
+
+// cachedTableName returns table name of the struct from cached metadata, resolving it if the type is not parsed yet
+func cachedTableName(s interface{}) string {
+	if direct := valueIfPtr(s); direct != nil {
+		if cached, ok := tableSchemas.Load(reflect.TypeOf(direct)); ok {
+			return cached.(*tableSchema).table.Name
+		}
+	}
+	return GetTableName(s)
+}
+
+// resetTableSchemas clears cached table metadata so it is parsed again on next use
+func resetTableSchemas() {
+	tableSchemas.Range(func(key, _ interface{}) bool {
+		tableSchemas.Delete(key)
+		return true
+	})
+}
+
+// newTable returns copy of the static metadata filled with values of the instance
+func (schema *tableSchema) newTable(instance interface{}) Table {
+	v := reflect.ValueOf(instance)
+	table := Table{
+		Name:     schema.table.Name,
+		Instance: instance,
+		Columns:  make([]Column, len(schema.table.Columns)),
+		FKeys:    make([]FKey, len(schema.table.FKeys)),
+		Uniq:     append([]CompositeFields(nil), schema.table.Uniq...),
+		Index:    append([]CompositeFields(nil), schema.table.Index...),
+	}
+	copy(table.Columns, schema.table.Columns)
+	copy(table.FKeys, schema.table.FKeys)
+	for i := range table.Columns {
+		column := &table.Columns[i]
+		column.Value = columnValue(v.Field(column.fieldIndex))
+		if column.isTimestampColumn() {
+			column.Value = utcValue(column.Value)
+		}
+	}
+	for i := range table.FKeys {
+		fKey := &table.FKeys[i]
+		fValue := reflect.Indirect(v.Field(fKey.fieldIndex))
+		if fValue.IsValid() {
+			fKey.ColumnValue = fValue.FieldByName("Id").Interface()
+		}
+	}
+	return table
+}
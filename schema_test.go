@@ -0,0 +1,129 @@
+package customorm
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type testMoney int64
+
+type testInvoice struct {
+	Id     int64     `customsql:"pkey:id"`
+	Amount testMoney `customsql:"amount"`
+}
+
+func TestRegisterTypeAfterFirstUse(t *testing.T) {
+	c := Init(nil)
+	table, err := c.GetTable(&testInvoice{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(table.Columns) != 1 {
+		t.Fatalf("unknown type column is created: %+v", table.Columns)
+	}
+	RegisterType(reflect.TypeOf(testMoney(0)), "NUMERIC")
+	table, err = c.GetTable(&testInvoice{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(table.Columns) != 2 || table.Columns[1].Type != "NUMERIC" {
+		t.Fatalf("registered type is not applied: %+v", table.Columns)
+	}
+}
+
+func BenchmarkGetTable(b *testing.B) {
+	c := Init(nil)
+	user := &testDomainUser{Id: 1, Name: "user", Parent: &testDomain{Id: 1}}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := c.GetTable(user); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkInsertRowToSQL(b *testing.B) {
+	c := Init(nil)
+	user := &testDomainUser{Name: "user", Parent: &testDomain{Id: 1}}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := c.InsertRowToSQL(user); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGetDataByValueToSQL(b *testing.B) {
+	c := Init(nil)
+	user := &testDomainUser{Name: "user", Parent: &testDomain{Id: 1}}
+	filter := Filters{Fields: map[string]FilterFields{"Name": {Flag: true}, "Parent": {Flag: true}}}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := c.GetDataByValueToSQL(user, filter, false); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkInsertRow(b *testing.B) {
+	db := openFakeDB()
+	defer db.Close()
+	c := Init(db)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := c.InsertRow(&testDomain{Name: "domain"}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGetDataByValue(b *testing.B) {
+	db := openFakeDBWith(&fakeDB{rows: func(string) ([]string, [][]driver.Value) { return []string{"id"}, nil }})
+	defer db.Close()
+	c := Init(db)
+	user := &testDomainUser{Name: "user", Parent: &testDomain{Id: 1}}
+	filter := Filters{Fields: map[string]FilterFields{"Name": {Flag: true}, "Parent": {Flag: true}}}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := c.GetDataByValue(user, filter, false); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+type testTicket struct {
+	Id     int64  `customsql:"pkey:id"`
+	Status string `customsql:"status;enum=ticket_status(open,closed)"`
+	Kind   string `customsql:"kind;enum=ticket_kind(bug,task)"`
+}
+
+func (t *testTicket) GetSchemaName() string {
+	return "support"
+}
+
+func TestCreateTableSchemaChanges(t *testing.T) {
+	fake := &fakeDB{rows: func(string) ([]string, [][]driver.Value) { return []string{"enumlabel"}, nil }}
+	db := openFakeDBWith(fake)
+	defer db.Close()
+	c := Init(db).SetStatementCache(10)
+	if _, err := c.exec("SELECT 1"); err != nil {
+		t.Fatal(err)
+	}
+	if !c.CreateTable(&testTicket{}) {
+		t.Fatal("table is not created")
+	}
+	var schemas int
+	for _, query := range fake.statements() {
+		if strings.HasPrefix(query, "CREATE SCHEMA") {
+			schemas++
+		}
+	}
+	if schemas != 1 {
+		t.Errorf("schema is created %d times, want once: %v", schemas, fake.statements())
+	}
+	if stats := c.StatementCacheStats(); stats.Size != 0 {
+		t.Errorf("statement cache is not cleared: %+v", stats)
+	}
+}
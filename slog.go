@@ -0,0 +1,49 @@
+//go:build go1.21
+// +build go1.21
+
+package customorm
+
+import (
+	"context"
+	"log/slog"
+)
+
+// SlogLogger adapts slog.Logger to Logger interface
+type SlogLogger struct {
+	logger *slog.Logger
+}
+
+// NewSlogLogger returns Logger writing statements to the given slog.Logger, nil uses slog.Default
+func NewSlogLogger(logger *slog.Logger) *SlogLogger {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &SlogLogger{logger: logger}
+}
+
+// LogQuery writes failed statements with error level, slow ones with warning level and others with debug level
+func (l *SlogLogger) LogQuery(ctx context.Context, event QueryEvent) {
+	level := slog.LevelDebug
+	msg := "customorm query"
+	switch {
+	case event.Err != nil:
+		level = slog.LevelError
+		msg = "customorm query failed"
+	case event.Slow:
+		level = slog.LevelWarn
+		msg = "customorm slow query"
+	}
+	if !l.logger.Enabled(ctx, level) {
+		return
+	}
+	attrs := []slog.Attr{
+		slog.String("sql", event.SQL),
+		slog.Any("args", event.Args),
+		slog.Duration("duration", event.Duration),
+		slog.Int64("rows", event.RowsAffected),
+	}
+	if event.Err != nil {
+		attrs = append(attrs, slog.String("error", event.Err.Error()))
+	}
+	l.logger.LogAttrs(ctx, level, msg, attrs...)
+}
@@ -0,0 +1,151 @@
+package customorm
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/lib/pq"
+)
+
+// Scopes of soft deleted rows visible to readers
+const (
+	deletedScopeExclude = iota
+	deletedScopeInclude
+	deletedScopeOnly
+)
+
+// softDeleteColumn returns the column marking row as deleted or nil for tables without soft delete
+func (table *Table) softDeleteColumn() *Column {
+	for i := range table.Columns {
+		if table.Columns[i].IsSoftDelete {
+			return &table.Columns[i]
+		}
+	}
+	return nil
+}
+
+// deletedCondition returns WHERE condition selecting rows visible in current soft delete scope,
+// column is qualified by the table alias if it is not empty
+func (c *CORM) deletedCondition(table Table, alias string) string {
+	column := table.softDeleteColumn()
+	if column == nil {
+		return ""
+	}
+	name := pq.QuoteIdentifier(column.Name)
+	if alias != "" {
+		name = pq.QuoteIdentifier(alias) + "." + name
+	}
+	switch c.deletedScope {
+	case deletedScopeInclude:
+		return ""
+	case deletedScopeOnly:
+		return name + " IS NOT NULL"
+	}
+	return name + " IS NULL"
+}
+
+// deleteSql returns statement removing rows matched by the condition or marking them as deleted for soft delete tables
+func (table *Table) deleteSql(where string) string {
+	column := table.softDeleteColumn()
+	if column == nil {
+		return fmt.Sprintf("DELETE FROM %s WHERE %s;", table.quotedName(), where)
+	}
+	name := pq.QuoteIdentifier(column.Name)
+	return fmt.Sprintf("UPDATE %s SET %s = now() WHERE %s AND %s IS NULL;", table.quotedName(), name, where, name)
+}
+
+// WithDeleted returns CORM copy which readers include soft deleted rows
+func (c *CORM) WithDeleted() *CORM {
+	n := *c
+	n.deletedScope = deletedScopeInclude
+	return &n
+}
+
+// OnlyDeleted returns CORM copy which readers return only soft deleted rows
+func (c *CORM) OnlyDeleted() *CORM {
+	n := *c
+	n.deletedScope = deletedScopeOnly
+	return &n
+}
+
+// rowId returns value of the Id field of the struct
+func rowId(s interface{}) (int64, error) {
+	direct := valueIfPtr(s)
+	if direct == nil {
+		return 0, errors.New("no table instance")
+	}
+	f := reflect.Indirect(reflect.ValueOf(direct)).FieldByName("Id")
+	if !f.IsValid() || f.Kind() != reflect.Int64 || f.Int() == 0 {
+		return 0, errors.New("no id value")
+	}
+	return f.Int(), nil
+}
+
+// Restore clears deletion mark of the soft deleted row
+func (c *CORM) Restore(s interface{}) error {
+	oc, op := c.startTableOperation("Restore", s)
+	err := oc.restore(s)
+	c.invalidateReadCache(op.table)
+	op.finish(err)
+	return err
+}
+
+func (c *CORM) restore(s interface{}) error {
+	table, err := c.writeTable(s)
+	if err != nil {
+		return err
+	}
+	column := table.softDeleteColumn()
+	if column == nil {
+		return errors.New("no soft delete column")
+	}
+	id, err := rowId(s)
+	if err != nil {
+		return err
+	}
+
+	where, args := table.tenantWhere(`"id" = $1`, []interface{}{id})
+	sqlReq := fmt.Sprintf(`UPDATE %s SET %s = NULL WHERE %s;`, table.quotedName(), pq.QuoteIdentifier(column.Name), where)
+	res, err := c.exec(sqlReq, args...)
+	if err != nil {
+		return err
+	}
+	err = table.checkTenantRows(res)
+	if err != nil {
+		return err
+	}
+	setFieldValue(s, column.FieldName, sql.NullTime{})
+
+	return nil
+}
+
+// HardDelete physically removes the row even if the table uses soft delete
+func (c *CORM) HardDelete(s interface{}) error {
+	oc, op := c.startTableOperation("HardDelete", s)
+	err := oc.hardDelete(s)
+	c.invalidateReadCacheCascade(op.table)
+	op.finish(err)
+	return err
+}
+
+func (c *CORM) hardDelete(s interface{}) error {
+	table, err := c.writeTable(s)
+	if err != nil {
+		return err
+	}
+	id, err := rowId(s)
+	if err != nil {
+		return err
+	}
+
+	where, args := table.tenantWhere(`"id" = $1`, []interface{}{id})
+	sqlReq := fmt.Sprintf(`DELETE FROM %s WHERE %s;`, table.quotedName(), where)
+	res, err := c.exec(sqlReq, args...)
+	if err != nil {
+		return err
+	}
+
+	return table.checkTenantRows(res)
+}
@@ -0,0 +1,247 @@
+package customorm
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"sync"
+)
+
+// StatementCacheStats holds counters of prepared statement cache
+type StatementCacheStats struct {
+	Hits      int64
+	Misses    int64
+	Evictions int64
+	Size      int
+}
+
+// HitRate returns share of statements served from the cache
+func (s StatementCacheStats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// statementCache keeps prepared statements keyed by generated SQL with LRU eviction
+type statementCache struct {
+	mu      sync.Mutex
+	db      *sql.DB
+	size    int
+	items   map[string]*list.Element
+	lru     *list.List
+	hits    int64
+	misses  int64
+	evicted int64
+}
+
+// statementCacheItem holds prepared statement with number of calls using it,
+// removed statement is closed when the last call releases it
+type statementCacheItem struct {
+	query   string
+	stmt    *sql.Stmt
+	refs    int
+	removed bool
+}
+
+func newStatementCache(db *sql.DB, size int) *statementCache {
+	return &statementCache{db: db, size: size, items: map[string]*list.Element{}, lru: list.New()}
+}
+
+// get returns prepared statement of the query preparing it on first use, caller releases it after the call
+func (sc *statementCache) get(ctx context.Context, query string) (*statementCacheItem, error) {
+	sc.mu.Lock()
+	if el, ok := sc.items[query]; ok {
+		sc.lru.MoveToFront(el)
+		sc.hits++
+		item := el.Value.(*statementCacheItem)
+		item.refs++
+		sc.mu.Unlock()
+		return item, nil
+	}
+	sc.misses++
+	sc.mu.Unlock()
+
+	stmt, err := sc.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if el, ok := sc.items[query]; ok {
+		// prepared concurrently by another call
+		stmt.Close()
+		sc.lru.MoveToFront(el)
+		item := el.Value.(*statementCacheItem)
+		item.refs++
+		return item, nil
+	}
+	item := &statementCacheItem{query: query, stmt: stmt, refs: 1}
+	sc.items[query] = sc.lru.PushFront(item)
+	for sc.lru.Len() > sc.size {
+		el := sc.lru.Back()
+		sc.remove(el)
+		sc.evicted++
+	}
+	return item, nil
+}
+
+// release marks end of the call using the statement, closing it if it was removed meanwhile
+func (sc *statementCache) release(item *statementCacheItem) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	item.refs--
+	if item.removed && item.refs == 0 {
+		item.stmt.Close()
+	}
+}
+
+// remove takes the statement out of the cache closing it unless it is used, called with lock held
+func (sc *statementCache) remove(el *list.Element) {
+	item := el.Value.(*statementCacheItem)
+	sc.lru.Remove(el)
+	delete(sc.items, item.query)
+	item.removed = true
+	if item.refs == 0 {
+		item.stmt.Close()
+	}
+}
+
+// invalidate closes and removes all prepared statements, statements in use are closed when released
+func (sc *statementCache) invalidate() {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	for el := sc.lru.Front(); el != nil; el = sc.lru.Front() {
+		sc.remove(el)
+	}
+}
+
+// stats returns cache counters
+func (sc *statementCache) stats() StatementCacheStats {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	return StatementCacheStats{Hits: sc.hits, Misses: sc.misses, Evictions: sc.evicted, Size: sc.lru.Len()}
+}
+
+// stmtExecutor runs prepared statement ignoring the query text
+type stmtExecutor struct {
+	stmt *sql.Stmt
+}
+
+func (e stmtExecutor) ExecContext(ctx context.Context, _ string, args ...interface{}) (sql.Result, error) {
+	return e.stmt.ExecContext(ctx, args...)
+}
+
+func (e stmtExecutor) QueryContext(ctx context.Context, _ string, args ...interface{}) (*sql.Rows, error) {
+	return e.stmt.QueryContext(ctx, args...)
+}
+
+func (e stmtExecutor) QueryRowContext(ctx context.Context, _ string, args ...interface{}) *sql.Row {
+	return e.stmt.QueryRowContext(ctx, args...)
+}
+
+// SetStatementCache enables cache of prepared statements holding up to size statements, 0 disables it
+func (c *CORM) SetStatementCache(size int) *CORM {
+	if c.stmts != nil {
+		c.stmts.invalidate()
+		c.stmts = nil
+	}
+	if size > 0 {
+		c.stmts = newStatementCache(c.db, size)
+	}
+	return c
+}
+
+// StatementCacheStats returns counters of prepared statement cache
+func (c *CORM) StatementCacheStats() StatementCacheStats {
+	if c.stmts == nil {
+		return StatementCacheStats{}
+	}
+	return c.stmts.stats()
+}
+
+// InvalidateStatementCache closes all cached prepared statements, e.g. after manual schema changes
+func (c *CORM) InvalidateStatementCache() {
+	if c.stmts != nil {
+		c.stmts.invalidate()
+	}
+	if c.txStmts != nil {
+		c.txStmts.invalidate()
+	}
+}
+
+// withoutStatementCache returns CORM copy running statements unprepared, used for schema changes and cursors
+func (c *CORM) withoutStatementCache() *CORM {
+	n := *c
+	n.stmts = nil
+	return &n
+}
+
+// schemaChange runs fn changing the database schema with CORM copy running statements unprepared,
+// cached statements are stale after schema changes, so the statement cache is cleared afterwards
+func (c *CORM) schemaChange(fn func(sc *CORM) error) error {
+	defer c.InvalidateStatementCache()
+	return fn(c.withoutStatementCache())
+}
+
+// noRelease is release function of executors not using cached statements
+func noRelease() {}
+
+// executorFor returns executor of the query using cached prepared statement when cache is enabled
+// and function releasing the statement, to be called when the executor call returns.
+// Rows returned by the call keep the statement open until they are closed.
+func (c *CORM) executorFor(ctx context.Context, query string) (Executor, func()) {
+	if c.stmts == nil {
+		return c.executor(), noRelease
+	}
+	sc := c.stmts
+	if c.tx != nil && c.txStmts != nil {
+		if stmt := c.txStmts.get(query); stmt != nil {
+			return stmtExecutor{stmt: stmt}, noRelease
+		}
+	}
+	item, err := sc.get(ctx, query)
+	if err != nil {
+		// statement errors are reported by the unprepared run
+		return c.executor(), noRelease
+	}
+	if c.tx != nil {
+		// transaction statement keeps the cached one open until the transaction is finished
+		stmt := c.tx.StmtContext(ctx, item.stmt)
+		sc.release(item)
+		if c.txStmts != nil {
+			c.txStmts.set(query, stmt)
+		}
+		return stmtExecutor{stmt: stmt}, noRelease
+	}
+	return stmtExecutor{stmt: item.stmt}, func() { sc.release(item) }
+}
+
+// txStatements keeps cached statements bound to transaction of WithTx copy, they are closed with the transaction
+type txStatements struct {
+	mu    sync.Mutex
+	stmts map[string]*sql.Stmt
+}
+
+func (ts *txStatements) get(query string) *sql.Stmt {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	return ts.stmts[query]
+}
+
+func (ts *txStatements) set(query string, stmt *sql.Stmt) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	ts.stmts[query] = stmt
+}
+
+// invalidate closes and removes all statements bound to the transaction
+func (ts *txStatements) invalidate() {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	for query, stmt := range ts.stmts {
+		stmt.Close()
+		delete(ts.stmts, query)
+	}
+}
@@ -0,0 +1,102 @@
+package customorm
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestStatementCacheConcurrentEviction(t *testing.T) {
+	c := Init(openFakeDB()).SetStatementCache(1)
+	var wg sync.WaitGroup
+	errs := make(chan error, 16)
+	for g := 0; g < 16; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 300; i++ {
+				query := fmt.Sprintf("SELECT %d", (g+i)%4)
+				var id int64
+				if err := c.queryRow(query, nil, &id); err != nil {
+					errs <- err
+					return
+				}
+				rows, st, err := c.query(query)
+				if err != nil {
+					errs <- err
+					return
+				}
+				for rows.Next() {
+				}
+				rows.Close()
+				st.finish(0, rows.Err())
+				if _, err := c.exec(query); err != nil {
+					errs <- err
+					return
+				}
+				if i%50 == 0 {
+					c.InvalidateStatementCache()
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+	if stats := c.StatementCacheStats(); stats.Evictions == 0 || stats.Size > 1 {
+		t.Fatalf("unexpected cache stats %+v", stats)
+	}
+}
+
+func TestStatementCacheEvictionKeepsUsedStatement(t *testing.T) {
+	c := Init(openFakeDB()).SetStatementCache(1)
+	ctx := context.Background()
+	ex, release := c.executorFor(ctx, "SELECT 1")
+	// evicts statement of the first query while it is in use
+	ex2, release2 := c.executorFor(ctx, "SELECT 2")
+	c.InvalidateStatementCache()
+	if _, err := ex.ExecContext(ctx, "SELECT 1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ex2.ExecContext(ctx, "SELECT 2"); err != nil {
+		t.Fatal(err)
+	}
+	release()
+	release2()
+	if _, err := ex.ExecContext(ctx, "SELECT 1"); err == nil {
+		t.Fatal("released evicted statement is not closed")
+	}
+}
+
+func TestStatementCacheTransactionStatements(t *testing.T) {
+	db := openFakeDB()
+	c := Init(db).SetStatementCache(1)
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tc := c.WithTx(tx)
+	ctx := context.Background()
+	ex, _ := tc.executorFor(ctx, "SELECT 1")
+	// evicts cached statement bound to the transaction
+	tc.executorFor(ctx, "SELECT 2")
+	ex2, _ := tc.executorFor(ctx, "SELECT 1")
+	if ex.(stmtExecutor).stmt != ex2.(stmtExecutor).stmt {
+		t.Error("transaction statement is bound again")
+	}
+	if _, err := ex2.ExecContext(ctx, "SELECT 1"); err != nil {
+		t.Fatal(err)
+	}
+	if stats := c.StatementCacheStats(); stats.Misses != 2 || stats.Hits != 0 {
+		t.Errorf("unexpected cache stats %+v", stats)
+	}
+	if err := tc.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ex2.ExecContext(ctx, "SELECT 1"); err == nil {
+		t.Error("transaction statement is not closed with the transaction")
+	}
+}
@@ -0,0 +1,110 @@
+package customorm
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// Subquery kinds
+const (
+	SubqueryIn        = "IN"
+	SubqueryExists    = "EXISTS"
+	SubqueryNotExists = "NOT EXISTS"
+)
+
+// Subquery struct defining condition on rows of another table related by foreign key.
+// SubqueryIn selects rows which key is among keys of the table rows matched by Filters,
+// SubqueryExists and SubqueryNotExists select rows having or not having related rows matched by Filters.
+type Subquery struct {
+	Kind    string
+	Table   interface{}
+	Filters Filters
+}
+
+func (f *Filters) subquery(kind string, s interface{}, filter Filters) *Filters {
+	if f.Error != nil {
+		return f
+	}
+	if filter.Error != nil {
+		f.Error = filter.Error
+		return f
+	}
+	f.Subqueries = append(f.Subqueries, Subquery{Kind: kind, Table: s, Filters: filter})
+	return f
+}
+
+// InSubquery adds condition selecting rows related by foreign key to rows of the table struct matched by filter
+func (f *Filters) InSubquery(s interface{}, filter Filters) *Filters {
+	return f.subquery(SubqueryIn, s, filter)
+}
+
+// Exists adds condition selecting rows having related rows of the table struct matched by filter
+func (f *Filters) Exists(s interface{}, filter Filters) *Filters {
+	return f.subquery(SubqueryExists, s, filter)
+}
+
+// NotExists adds condition selecting rows not having related rows of the table struct matched by filter
+func (f *Filters) NotExists(s interface{}, filter Filters) *Filters {
+	return f.subquery(SubqueryNotExists, s, filter)
+}
+
+// addSubqueries adds conditions of the filter subqueries, arguments of subqueries continue numbering of the query
+func (c *CORM) addSubqueries(q *selectQuery, filter Filters) error {
+	for i, sub := range filter.Subqueries {
+		switch sub.Kind {
+		case SubqueryIn, SubqueryExists, SubqueryNotExists:
+		default:
+			return fmt.Errorf("unknown subquery kind %s", sub.Kind)
+		}
+		if sub.Filters.Error != nil {
+			return sub.Filters.Error
+		}
+		if sub.Table == nil {
+			return errors.New("no subquery table")
+		}
+		table, err := c.GetTable(sub.Table)
+		if err != nil {
+			return err
+		}
+		outerColumn, innerColumn, err := relatedColumns(q.table, table)
+		if err != nil {
+			return err
+		}
+		sq := &selectQuery{table: table, alias: q.alias + "_s" + strconv.Itoa(i+1), args: q.args}
+		err = c.buildSelectQuery(sq, Filters{
+			Fields:     sub.Filters.Fields,
+			Joins:      sub.Filters.Joins,
+			Subqueries: sub.Filters.Subqueries,
+		}, true)
+		if err != nil {
+			return err
+		}
+		q.args = sq.args
+		q.related = true
+
+		if sub.Kind == SubqueryIn {
+			q.wheres = append(q.wheres, fmt.Sprintf("%s IN (SELECT %s FROM %s%s)",
+				q.column(outerColumn), sq.column(innerColumn), sq.from(), sq.where()))
+			continue
+		}
+		sq.wheres = append([]string{sq.column(innerColumn) + " = " + q.column(outerColumn)}, sq.wheres...)
+		q.wheres = append(q.wheres, fmt.Sprintf("%s (SELECT 1 FROM %s%s)", sub.Kind, sq.from(), sq.where()))
+	}
+	return nil
+}
+
+// relatedColumns returns columns of outer and inner tables related by foreign key
+func relatedColumns(outer, inner Table) (string, string, error) {
+	for _, v := range inner.FKeys {
+		if v.TableName == outer.Name && v.TableSchema == outer.Schema {
+			return v.TableColumnName, v.ColumnName, nil
+		}
+	}
+	for _, v := range outer.FKeys {
+		if v.TableName == inner.Name && v.TableSchema == inner.Schema {
+			return v.ColumnName, v.TableColumnName, nil
+		}
+	}
+	return "", "", fmt.Errorf("no foreign key between tables %s and %s", outer.Name, inner.Name)
+}
@@ -0,0 +1,136 @@
+package customorm
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/lib/pq"
+)
+
+// ForTenant returns CORM copy scoped to the tenant, reads and writes of tables with tenant column
+// are limited to its rows and inserted rows get the tenant id. Nil id removes the scope.
+func (c *CORM) ForTenant(id interface{}) *CORM {
+	n := *c
+	n.tenant = id
+	return &n
+}
+
+// tenantColumn returns the column holding row tenant or nil for tables without tenant
+func (table *Table) tenantColumn() *Column {
+	for i := range table.Columns {
+		if table.Columns[i].IsTenant {
+			return &table.Columns[i]
+		}
+	}
+	return nil
+}
+
+// tenantValue returns tenant id converted to the type of the tenant column
+func (c *CORM) tenantValue(column *Column) (interface{}, error) {
+	v := reflect.ValueOf(c.tenant)
+	t := reflect.TypeOf(column.Value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if t.Kind() == reflect.Int64 {
+			return v.Convert(t).Interface(), nil
+		}
+	case reflect.String:
+		if t.Kind() == reflect.String {
+			return v.Convert(t).Interface(), nil
+		}
+	}
+	return nil, fmt.Errorf("tenant id %v does not match type of column %s", c.tenant, column.Name)
+}
+
+// addTenantCondition limits the query to rows of the scoped tenant
+func (c *CORM) addTenantCondition(q *selectQuery) error {
+	column := q.table.tenantColumn()
+	if column == nil || c.tenant == nil {
+		return nil
+	}
+	value, err := c.tenantValue(column)
+	if err != nil {
+		return err
+	}
+	q.wheres = append(q.wheres, q.column(column.Name)+" = "+q.arg(value))
+	return nil
+}
+
+// joinTenantCondition returns condition limiting the joined table to rows of the scoped tenant
+func (c *CORM) joinTenantCondition(q *selectQuery, j *selectJoin) (string, error) {
+	column := j.table.tenantColumn()
+	if column == nil || c.tenant == nil {
+		return "", nil
+	}
+	value, err := c.tenantValue(column)
+	if err != nil {
+		return "", err
+	}
+	return j.column(column.Name) + " = " + q.arg(value), nil
+}
+
+// scopeTenant sets empty tenant column of the table row to the scoped tenant and adds tenant condition
+// to its write statements, rows of other tenants are refused with ErrTenantMismatch
+func (c *CORM) scopeTenant(table *Table) error {
+	column := table.tenantColumn()
+	if column == nil || c.tenant == nil || table.tenantScoped {
+		return nil
+	}
+	value, err := c.tenantValue(column)
+	if err != nil {
+		return err
+	}
+	if !reflect.ValueOf(column.Value).IsZero() && column.Value != value {
+		return ErrTenantMismatch
+	}
+	column.Value = value
+	table.tenantScoped = true
+	return nil
+}
+
+// writeTable returns table of the struct written in tenant scope
+func (c *CORM) writeTable(s interface{}) (Table, error) {
+	table, err := c.GetTable(s)
+	if err != nil {
+		return table, err
+	}
+	err = c.scopeTenant(&table)
+	if err != nil {
+		return Table{}, err
+	}
+	return table, nil
+}
+
+// writeTenant sets tenant id of the written row into the struct passed by pointer
+func (table *Table) writeTenant(s interface{}) {
+	if column := table.tenantColumn(); column != nil && table.tenantScoped {
+		setFieldValue(s, column.FieldName, column.Value)
+	}
+}
+
+// checkTenantRows refuses tenant scoped write by id which found no row of the tenant with ErrTenantMismatch
+func (table *Table) checkTenantRows(res sql.Result) error {
+	if !table.tenantScoped {
+		return nil
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrTenantMismatch
+	}
+	return nil
+}
+
+// tenantWhere appends condition of the scoped tenant to the statement condition using next placeholder
+func (table *Table) tenantWhere(where string, args []interface{}) (string, []interface{}) {
+	column := table.tenantColumn()
+	if column == nil || !table.tenantScoped {
+		return where, args
+	}
+	args = append(args, column.Value)
+	return where + " AND " + pq.QuoteIdentifier(column.Name) + " = $" + strconv.Itoa(len(args)), args
+}
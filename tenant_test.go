@@ -0,0 +1,40 @@
+package customorm
+
+import (
+	"testing"
+)
+
+func TestTenantWriteOfOtherTenantRow(t *testing.T) {
+	db := openFakeDBWith(&fakeDB{rowsAffected: 0})
+	defer db.Close()
+	c := Init(db).ForTenant(7)
+	writes := []struct {
+		name string
+		run  func(o *testOrder) error
+	}{
+		{"UpdateRow", func(o *testOrder) error { return c.UpdateRow(o, true, map[string]bool{"Number": true}) }},
+		{"DeleteRowById", func(o *testOrder) error { return c.DeleteRowById(o) }},
+		{"HardDelete", func(o *testOrder) error { return c.HardDelete(o) }},
+	}
+	for _, w := range writes {
+		o := &testOrder{Id: 1, Number: "A-1", User: &testAccount{Id: 2}}
+		if err := w.run(o); err != ErrTenantMismatch {
+			t.Errorf("%s: got error %v, want ErrTenantMismatch", w.name, err)
+		}
+		if o.TenantId != 0 {
+			t.Errorf("%s: tenant id %d is set into struct of failed write", w.name, o.TenantId)
+		}
+	}
+}
+
+func TestTenantWriteSetsTenant(t *testing.T) {
+	db := openFakeDB()
+	defer db.Close()
+	o := &testOrder{Id: 1, Number: "A-1", User: &testAccount{Id: 2}}
+	if err := Init(db).ForTenant(7).UpdateRow(o, true, map[string]bool{"Number": true}); err != nil {
+		t.Fatal(err)
+	}
+	if o.TenantId != 7 {
+		t.Errorf("tenant id: got %d, want 7", o.TenantId)
+	}
+}
@@ -0,0 +1,67 @@
+package customorm
+
+import (
+	"database/sql"
+	"reflect"
+	"time"
+)
+
+// Column types of time values
+const (
+	timestampType   = "TIMESTAMP"
+	timestampTZType = "TIMESTAMPTZ"
+	dateType        = "DATE"
+	timeType        = "TIME"
+)
+
+var (
+	timeValueType = reflect.TypeOf(time.Time{})
+	nullTimeType  = reflect.TypeOf(sql.NullTime{})
+)
+
+// isTimeType checks if the field type holds a timestamp value
+func isTimeType(t reflect.Type) bool {
+	return t == timeValueType || t == nullTimeType
+}
+
+// isTimestampColumn checks if the column stores date and time which are normalized to UTC
+func (c *Column) isTimestampColumn() bool {
+	return c.Type == timestampType || c.Type == timestampTZType
+}
+
+// utcValue normalizes time values to UTC before writing
+func utcValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case time.Time:
+		if v.IsZero() {
+			return v
+		}
+		return v.UTC()
+	case sql.NullTime:
+		if v.Valid {
+			v.Time = v.Time.UTC()
+		}
+		return v
+	}
+	return value
+}
+
+// normalizeTimes converts time fields of the scanned struct to UTC
+func normalizeTimes(v reflect.Value) {
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Field(i)
+		if !f.CanSet() {
+			continue
+		}
+		switch f.Type() {
+		case timeValueType, nullTimeType:
+			f.Set(reflect.ValueOf(utcValue(f.Interface())))
+		}
+	}
+}
+
+// SetTimestampTZ makes all TIMESTAMP columns to be created as TIMESTAMPTZ
+func (c *CORM) SetTimestampTZ(enabled bool) *CORM {
+	c.timestampTZ = enabled
+	return c
+}
@@ -0,0 +1,84 @@
+package customorm
+
+import "errors"
+
+// writeStatement holds generated statement of write operation
+type writeStatement struct {
+	sql       string
+	args      []interface{}
+	returning returningColumns
+	// version column is checked by the statement
+	versioned bool
+	// position is changed by MovePosition statements before the update
+	movePosition bool
+}
+
+// InsertRowToSQL returns statement and arguments InsertRow would run for the table struct without running it.
+// Hooks are not called.
+func (c *CORM) InsertRowToSQL(s interface{}) (string, []interface{}, error) {
+	table, err := c.GetTable(s)
+	if err != nil {
+		return "", nil, err
+	}
+	err = c.scopeTenant(&table)
+	if err != nil {
+		return "", nil, err
+	}
+	st, err := table.insertStatement()
+	if err != nil {
+		return "", nil, err
+	}
+	return st.sql, st.args, nil
+}
+
+// UpdateRowToSQL returns statement and arguments UpdateRow would run for the table struct without running it.
+// Hooks are not called, position changes made by MovePosition are not included.
+func (c *CORM) UpdateRowToSQL(s interface{}, onlyFields bool, fieldNames map[string]bool) (string, []interface{}, error) {
+	table, err := c.GetTable(s)
+	if err != nil {
+		return "", nil, err
+	}
+	err = c.scopeTenant(&table)
+	if err != nil {
+		return "", nil, err
+	}
+	st, err := table.updateStatement(onlyFields, fieldNames)
+	if err != nil {
+		return "", nil, err
+	}
+	if st.sql == "" {
+		return "", nil, errors.New("position is updated by MovePosition statements only")
+	}
+	return st.sql, st.args, nil
+}
+
+// DeleteRowsToSQL returns statement and arguments DeleteRows would run for the table struct without running it.
+// Hooks are not called.
+func (c *CORM) DeleteRowsToSQL(s interface{}, fieldNames map[string]bool) (string, []interface{}, error) {
+	table, err := c.GetTable(s)
+	if err != nil {
+		return "", nil, err
+	}
+	err = c.scopeTenant(&table)
+	if err != nil {
+		return "", nil, err
+	}
+	st, err := table.deleteStatement(fieldNames)
+	if err != nil {
+		return "", nil, err
+	}
+	return st.sql, st.args, nil
+}
+
+// GetDataByValueToSQL returns statement and arguments GetDataByValue would run for the table struct and filter
+// without running it
+func (c *CORM) GetDataByValueToSQL(s interface{}, filter Filters, asMap bool) (string, []interface{}, error) {
+	q, _, err := c.getDataByValueQuery(s, filter, asMap)
+	if err != nil {
+		return "", nil, err
+	}
+	if filter.Count {
+		return q.countSql(), q.args, nil
+	}
+	return q.selectSql() + ";", q.args, nil
+}
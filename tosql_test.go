@@ -0,0 +1,182 @@
+package customorm
+
+import (
+	"reflect"
+	"testing"
+)
+
+// testOrder uses reserved word as table name, own schema and tenant column
+type testOrder struct {
+	Id       int64        `customsql:"pkey:id"`
+	TenantId int64        `customsql:"tenant_id;tenant"`
+	Number   string       `customsql:"number"`
+	User     *testAccount `customsql:"fkey:user"`
+}
+
+func (o *testOrder) GetTableName() string {
+	return "order"
+}
+
+func (o *testOrder) GetSchemaName() string {
+	return "billing"
+}
+
+type testAccount struct {
+	Id       int64  `customsql:"pkey:id"`
+	TenantId int64  `customsql:"tenant_id;tenant"`
+	Name     string `customsql:"name"`
+}
+
+type sqlSnapshot struct {
+	name  string
+	run   func() (string, []interface{}, error)
+	query string
+	args  []interface{}
+}
+
+func checkSnapshots(t *testing.T, snapshots []sqlSnapshot) {
+	for _, s := range snapshots {
+		query, args, err := s.run()
+		if err != nil {
+			t.Errorf("%s: %v", s.name, err)
+			continue
+		}
+		if query != s.query {
+			t.Errorf("%s:\n got %s\nwant %s", s.name, query, s.query)
+		}
+		if !reflect.DeepEqual(args, s.args) {
+			t.Errorf("%s: got args %#v, want %#v", s.name, args, s.args)
+		}
+	}
+}
+
+func TestWriteToSQL(t *testing.T) {
+	c := Init(nil)
+	user := &testDomainUser{Id: 3, Name: "bob", Version: 2, Parent: &testDomain{Id: 1}}
+	order := &testOrder{Id: 5, Number: "A-1", User: &testAccount{Id: 2}}
+	checkSnapshots(t, []sqlSnapshot{
+		{
+			name:  "insert",
+			run:   func() (string, []interface{}, error) { return c.InsertRowToSQL(&testDomain{Name: "example"}) },
+			query: `INSERT INTO "domains"("enabled", "name") VALUES($1, $2) returning "id";`,
+			args:  []interface{}{false, "example"},
+		},
+		{
+			name: "update only fields",
+			run: func() (string, []interface{}, error) {
+				return c.UpdateRowToSQL(user, true, map[string]bool{"Name": true})
+			},
+			query: `UPDATE "domain_users" SET "name"=$1, "updated_at" = now(), "version" = "version" + 1 WHERE "id" = $2 AND "version" = $3 RETURNING "updated_at", "version";`,
+			args:  []interface{}{"bob", int64(3), int64(2)},
+		},
+		{
+			name: "update position only",
+			run: func() (string, []interface{}, error) {
+				return c.UpdateRowToSQL(user, true, map[string]bool{"Position": true})
+			},
+			query: `UPDATE "domain_users" SET "updated_at" = now(), "version" = "version" + 1 WHERE "id" = $1 AND "version" = $2 RETURNING "updated_at", "version";`,
+			args:  []interface{}{int64(3), int64(2)},
+		},
+		{
+			name: "soft delete",
+			run: func() (string, []interface{}, error) {
+				return c.DeleteRowsToSQL(user, map[string]bool{"Name": true, "Parent": true})
+			},
+			query: `UPDATE "domain_users" SET "deleted_at" = now() WHERE "name"=$1 AND "parent_id"=$2 AND "deleted_at" IS NULL;`,
+			args:  []interface{}{"bob", int64(1)},
+		},
+		{
+			name:  "insert with schema and tenant",
+			run:   func() (string, []interface{}, error) { return c.ForTenant(7).InsertRowToSQL(order) },
+			query: `INSERT INTO "billing"."order"("user", "tenant_id", "number") VALUES($1, $2, $3) returning "id";`,
+			args:  []interface{}{int64(2), int64(7), "A-1"},
+		},
+		{
+			name: "update with schema and tenant",
+			run: func() (string, []interface{}, error) {
+				return c.ForTenant(7).UpdateRowToSQL(order, true, map[string]bool{"Number": true})
+			},
+			query: `UPDATE "billing"."order" SET "number"=$1 WHERE "id" = $2 AND "tenant_id" = $3;`,
+			args:  []interface{}{"A-1", int64(5), int64(7)},
+		},
+		{
+			name: "delete with schema and tenant",
+			run: func() (string, []interface{}, error) {
+				return c.ForTenant(7).DeleteRowsToSQL(order, map[string]bool{"Number": true})
+			},
+			query: `DELETE FROM "billing"."order" WHERE "number"=$1 AND "tenant_id" = $2;`,
+			args:  []interface{}{"A-1", int64(7)},
+		},
+	})
+	_, _, err := c.ForTenant(8).UpdateRowToSQL(&testOrder{Id: 5, TenantId: 7, User: &testAccount{Id: 2}}, false, nil)
+	if err != ErrTenantMismatch {
+		t.Errorf("update of other tenant row: got %v, want ErrTenantMismatch", err)
+	}
+}
+
+func TestGetDataByValueToSQL(t *testing.T) {
+	c := Init(nil)
+	byName := Filters{Fields: map[string]FilterFields{"Name": {Flag: true}}}
+	distinct := Filters{Select: []string{"Name"}, Distinct: true, Fields: map[string]FilterFields{"Enabled": {Flag: true}}}
+	distinctOrder := distinct
+	distinctOrder.Order = Order{Fields: []string{"Name"}, Desc: true}
+	joined := Filters{Fields: map[string]FilterFields{
+		"Name":        {Flag: true, UseValue: true, Value: "bob"},
+		"Parent.Name": {Flag: true, UseValue: true, Value: "example"},
+	}, Order: Order{Fields: []string{"Parent.Name"}}}
+	joined.InnerJoin("Parent")
+	subquery := Filters{Fields: map[string]FilterFields{"Name": {Flag: true, UseValue: true, Value: "example"}}}
+	subquery.Exists(&testDomainUser{}, Filters{Fields: map[string]FilterFields{"Name": {Flag: true, UseValue: true, Value: "bob"}}}).
+		InSubquery(&testDomainUser{}, Filters{Fields: map[string]FilterFields{"Enabled": {Flag: true, UseValue: true, Value: true}}})
+	orders := Filters{Fields: map[string]FilterFields{"Number": {Flag: true, UseValue: true, Value: "A-1"}}}
+	orders.LeftJoin("User")
+
+	user := &testDomainUser{Name: "bob", Parent: &testDomain{Id: 1}}
+	checkSnapshots(t, []sqlSnapshot{
+		{
+			name:  "filter",
+			run:   func() (string, []interface{}, error) { return c.GetDataByValueToSQL(user, byName, false) },
+			query: `SELECT "id", "position", "enabled", "name", "updated_at", "deleted_at", "version", "parent_id" FROM "domain_users" WHERE "name" = $1 AND "deleted_at" IS NULL ORDER BY "parent_id", "position" LIMIT 100000;`,
+			args:  []interface{}{"bob"},
+		},
+		{
+			name:  "distinct",
+			run:   func() (string, []interface{}, error) { return c.GetDataByValueToSQL(user, distinct, false) },
+			query: `SELECT DISTINCT "name" FROM "domain_users" WHERE "enabled" = $1 AND "deleted_at" IS NULL LIMIT 100000;`,
+			args:  []interface{}{false},
+		},
+		{
+			name:  "distinct ordered",
+			run:   func() (string, []interface{}, error) { return c.GetDataByValueToSQL(user, distinctOrder, false) },
+			query: `SELECT DISTINCT "name" FROM "domain_users" WHERE "enabled" = $1 AND "deleted_at" IS NULL ORDER BY "name" DESC LIMIT 100000;`,
+			args:  []interface{}{false},
+		},
+		{
+			name:  "join",
+			run:   func() (string, []interface{}, error) { return c.GetDataByValueToSQL(user, joined, false) },
+			query: `SELECT "t"."id", "t"."position", "t"."enabled", "t"."name", "t"."updated_at", "t"."deleted_at", "t"."version", "t"."parent_id" FROM "domain_users" AS "t" INNER JOIN "domains" AS "parent" ON "parent"."id" = "t"."parent_id" WHERE "t"."name" = $1 AND "parent"."name" = $2 AND "t"."deleted_at" IS NULL ORDER BY "parent"."name" ASC LIMIT 100000;`,
+			args:  []interface{}{"bob", "example"},
+		},
+		{
+			name:  "subqueries",
+			run:   func() (string, []interface{}, error) { return c.GetDataByValueToSQL(&testDomain{}, subquery, false) },
+			query: `SELECT "t"."id", "t"."enabled", "t"."name" FROM "domains" AS "t" WHERE "t"."name" = $1 AND EXISTS (SELECT 1 FROM "domain_users" AS "t_s1" WHERE "t_s1"."parent_id" = "t"."id" AND "t_s1"."name" = $2 AND "t_s1"."deleted_at" IS NULL) AND "t"."id" IN (SELECT "t_s2"."parent_id" FROM "domain_users" AS "t_s2" WHERE "t_s2"."enabled" = $3 AND "t_s2"."deleted_at" IS NULL) LIMIT 100000;`,
+			args:  []interface{}{"example", "bob", true},
+		},
+		{
+			name: "schema and tenant",
+			run: func() (string, []interface{}, error) {
+				return c.ForTenant(7).GetDataByValueToSQL(&testOrder{}, orders, false)
+			},
+			query: `SELECT "t"."id", "t"."tenant_id", "t"."number", "t"."user" FROM "billing"."order" AS "t" LEFT JOIN "test_account" AS "user" ON "user"."id" = "t"."user" AND "user"."tenant_id" = $1 WHERE "t"."number" = $2 AND "t"."tenant_id" = $3 ORDER BY "t"."user" LIMIT 100000;`,
+			args:  []interface{}{int64(7), "A-1", int64(7)},
+		},
+	})
+	distinctOrder.Order.Fields = []string{"Position"}
+	if _, _, err := c.GetDataByValueToSQL(user, distinctOrder, false); err == nil {
+		t.Error("distinct order by not selected field is accepted")
+	}
+	if _, _, err := c.GetDataByValueToSQL(user, Filters{Fields: map[string]FilterFields{"id; DROP TABLE x": {Flag: true}}}, false); err == nil {
+		t.Error("unknown filter field is accepted")
+	}
+}
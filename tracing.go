@@ -0,0 +1,172 @@
+package customorm
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Span attribute keys set by CORM
+const (
+	AttrOperation = "db.operation"
+	AttrTable     = "db.table"
+	AttrStatement = "db.statement"
+	AttrRows      = "db.rows"
+)
+
+// Span names of CORM statements, operation spans are named after CORM method
+const statementSpanName = "customorm.query"
+
+// Tracer interface starting spans of CORM operations and statements
+type Tracer interface {
+	StartSpan(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span interface of single traced operation or statement
+type Span interface {
+	SetAttribute(key string, value interface{})
+	RecordError(err error)
+	End()
+}
+
+// SetTracer sets tracer receiving spans of CORM operations and statements, nil disables tracing
+func (c *CORM) SetTracer(tracer Tracer) *CORM {
+	c.tracer = tracer
+	return c
+}
+
+// operation tracks a single call of CORM method
+type operation struct {
+	c          *CORM
+	name       string
+	table      string
+	start      time.Time
+	span       Span
+	rows       int64
+	capReached bool
+}
+
+// startOperation begins tracking of CORM method and returns CORM copy running its statements
+func (c *CORM) startOperation(name string, table string) (*CORM, *operation) {
+	op := &operation{c: c, name: name, table: table, start: time.Now()}
+	n := *c
+	n.op = op
+	if c.tracer != nil {
+		n.ctx, op.span = c.tracer.StartSpan(c.getContext(), name)
+		op.span.SetAttribute(AttrOperation, name)
+		op.span.SetAttribute(AttrTable, table)
+	}
+	return &n, op
+}
+
+// startTableOperation begins tracking of CORM method called for the table struct
+func (c *CORM) startTableOperation(name string, s interface{}) (*CORM, *operation) {
+	tableName := ""
+	if s != nil {
+		tableName = cachedTableName(s)
+	}
+	return c.startOperation(name, tableName)
+}
+
+// addRows adds rows affected or returned by the operation statement
+func (o *operation) addRows(rows int64) {
+	o.rows += rows
+}
+
+// finish reports the operation to metrics and ends its span
+func (o *operation) finish(err error) {
+	o.c.observeOperation(o, err)
+	if o.span == nil {
+		return
+	}
+	o.span.SetAttribute(AttrRows, o.rows)
+	if err != nil {
+		o.span.RecordError(err)
+	}
+	o.span.End()
+}
+
+// finishPanic ends the operation of panicking CORM method and continues panicking
+func (o *operation) finishPanic() {
+	if r := recover(); r != nil {
+		o.finish(fmt.Errorf("%v", r))
+		panic(r)
+	}
+	o.finish(nil)
+}
+
+// RecordedSpan is a span stored by SpanRecorder
+type RecordedSpan struct {
+	Name       string
+	Parent     string
+	Attributes map[string]interface{}
+	Err        error
+	Start      time.Time
+	End        time.Time
+}
+
+// SpanRecorder is an in-memory Tracer keeping finished spans, useful for tests
+type SpanRecorder struct {
+	mu    sync.Mutex
+	spans []RecordedSpan
+}
+
+type recorderSpanKey struct{}
+
+// recorderSpan is an active span of SpanRecorder
+type recorderSpan struct {
+	recorder *SpanRecorder
+	data     RecordedSpan
+}
+
+// NewSpanRecorder returns empty in-memory tracer
+func NewSpanRecorder() *SpanRecorder {
+	return &SpanRecorder{}
+}
+
+// StartSpan starts span as a child of the span in context
+func (r *SpanRecorder) StartSpan(ctx context.Context, name string) (context.Context, Span) {
+	span := &recorderSpan{
+		recorder: r,
+		data:     RecordedSpan{Name: name, Attributes: map[string]interface{}{}, Start: time.Now()},
+	}
+	if parent, ok := ctx.Value(recorderSpanKey{}).(*recorderSpan); ok {
+		span.data.Parent = parent.data.Name
+	}
+	return context.WithValue(ctx, recorderSpanKey{}, span), span
+}
+
+// Spans returns finished spans in order of ending
+func (r *SpanRecorder) Spans() []RecordedSpan {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	res := make([]RecordedSpan, len(r.spans))
+	copy(res, r.spans)
+	return res
+}
+
+// Reset removes finished spans
+func (r *SpanRecorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spans = nil
+}
+
+// SetAttribute sets span attribute
+func (s *recorderSpan) SetAttribute(key string, value interface{}) {
+	s.data.Attributes[key] = value
+}
+
+// RecordError stores span error
+func (s *recorderSpan) RecordError(err error) {
+	s.data.Err = err
+}
+
+// End finishes span and stores it in the recorder
+func (s *recorderSpan) End() {
+	s.data.End = time.Now()
+	s.recorder.mu.Lock()
+	defer s.recorder.mu.Unlock()
+	s.recorder.spans = append(s.recorder.spans, s.data)
+}
@@ -0,0 +1,69 @@
+package customorm
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+	"sync"
+)
+
+// defaultCustomType is a column type for Valuer/Scanner types without registered SQL type
+const defaultCustomType = "TEXT"
+
+var (
+	valuerType  = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+	scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+)
+
+// typeRegistry holds SQL column types of Go types unknown to the built-in mapping
+var typeRegistry = struct {
+	sync.RWMutex
+	types map[reflect.Type]string
+}{
+	types: map[reflect.Type]string{
+		reflect.TypeOf(sql.NullString{}):  "VARCHAR",
+		reflect.TypeOf(sql.NullInt64{}):   "BIGINT",
+		reflect.TypeOf(sql.NullInt32{}):   "INTEGER",
+		reflect.TypeOf(sql.NullFloat64{}): "FLOAT",
+		reflect.TypeOf(sql.NullBool{}):    "BOOLEAN",
+		reflect.TypeOf(sql.NullTime{}):    "TIMESTAMP",
+	},
+}
+
+// RegisterType registers SQL column type used for struct fields of the given Go type,
+// cached table metadata is cleared to apply it to already used structs
+func RegisterType(t reflect.Type, sqlType string) {
+	if t == nil || sqlType == "" {
+		return
+	}
+	typeRegistry.Lock()
+	typeRegistry.types[t] = sqlType
+	typeRegistry.Unlock()
+	resetTableSchemas()
+}
+
+// registeredType returns SQL column type registered for the given Go type
+func registeredType(t reflect.Type) (string, bool) {
+	typeRegistry.RLock()
+	defer typeRegistry.RUnlock()
+	sqlType, ok := typeRegistry.types[t]
+	return sqlType, ok
+}
+
+// isValuerScanner checks if the type can be written with driver.Valuer and read with sql.Scanner
+func isValuerScanner(t reflect.Type) bool {
+	if !t.Implements(valuerType) && !reflect.PtrTo(t).Implements(valuerType) {
+		return false
+	}
+	return reflect.PtrTo(t).Implements(scannerType)
+}
+
+// columnValue returns field value usable as a query argument, addressing it for pointer receiver Valuer types
+func columnValue(v reflect.Value) interface{} {
+	if v.Kind() != reflect.Ptr && !v.Type().Implements(valuerType) && reflect.PtrTo(v.Type()).Implements(valuerType) {
+		ptr := reflect.New(v.Type())
+		ptr.Elem().Set(v)
+		return ptr.Interface()
+	}
+	return v.Interface()
+}